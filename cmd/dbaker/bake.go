@@ -0,0 +1,45 @@
+package main
+
+import (
+	"dbaker/pkg/action"
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+
+	"github.com/spf13/cobra"
+)
+
+func newBakeCommand() *cobra.Command {
+	var config config.Config
+	bakeCmd := cobra.Command{
+		Use:     "bake",
+		Aliases: []string{"b"},
+		Short:   "Introspect and generate fake data in one go",
+		Long:    "Introspect the tables, apply an optional overlay recipe of overrides and generate fake data right away, e.g. into throwaway databases, without writing a recipe unless asked",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			sourceAdapter := adapter.NewPostgreSQLAdapter(config)
+			targetAdapter := adapter.NewPostgreSQLAdapter(config.TargetConfig())
+			action := action.NewBake(config, sourceAdapter, targetAdapter)
+
+			return action.Execute()
+		},
+	}
+
+	bindConnectionFlags(&bakeCmd, &config)
+	bindRecipeFlag(&bakeCmd, &config)
+	bindTargetConnectionFlags(&bakeCmd, &config, false)
+	bindTransactionFlags(&bakeCmd, &config)
+	bindCleanFlags(&bakeCmd, &config)
+	bakeCmd.Flags().StringArrayVarP(&config.Tables, "tables", "t", []string{}, "tables to bake")
+	bakeCmd.Flags().StringVarP(&config.OverlayPath, "overlay", "o", "", "recipe of overrides (annotations, row counts, distributions, ...) listing only the tables and columns to change")
+	bakeCmd.Flags().BoolVar(&config.SaveRecipe, "save-recipe", false, "write the baked recipe to the recipe path")
+	bakeCmd.Flags().Uint32VarP(&config.DataSize, "size", "s", 0, "dataset size, number of rows to generate for tables without rowCount")
+	bakeCmd.Flags().Uint32VarP(&config.IterFrom, "iterFrom", "i", 0, "iteration index from which to start generating unique values")
+	bakeCmd.Flags().Float64Var(&config.NullRatio, "null-ratio", 0, "default probability (0-1) of generating NULL for nullable columns")
+	bindUniqueFlags(&bakeCmd, &config)
+	bindStateFlags(&bakeCmd, &config)
+	bindOutputFlags(&bakeCmd, &config)
+
+	bakeCmd.MarkFlagRequired("tables")
+
+	return &bakeCmd
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"dbaker/pkg/action"
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+
+	"github.com/spf13/cobra"
+)
+
+func newCleanCommand() *cobra.Command {
+	var config config.Config
+	cleanCmd := cobra.Command{
+		Use:     "clean",
+		Aliases: []string{"c"},
+		Short:   "Remove generated data",
+		Long:    "Delete the rows a generate run of the given iterations inserted into the recipe tables (found by the tag column or the unique values), or truncate the tables",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			pgAdapter := adapter.NewPostgreSQLAdapter(config.TargetConfig())
+			action := action.NewClean(config, pgAdapter)
+
+			return action.Execute()
+		},
+	}
+
+	bindConnectionFlags(&cleanCmd, &config)
+	bindRecipeFlag(&cleanCmd, &config)
+	bindTargetConnectionFlags(&cleanCmd, &config, false)
+	cleanCmd.Flags().BoolVar(&config.Truncate, "truncate", false, "truncate the recipe tables (restart identity cascade) instead of deleting the generated rows")
+	cleanCmd.Flags().StringVar(&config.Tag, "tag", action.DefaultTag, "tag of the generated rows in the tag columns")
+	cleanCmd.Flags().Uint32VarP(&config.DataSize, "size", "s", 0, "dataset size the rows were generated with, for tables without rowCount in the recipe")
+	cleanCmd.Flags().Uint32VarP(&config.IterFrom, "iterFrom", "i", 0, "iteration index the rows were generated from")
+	bindUniqueFlags(&cleanCmd, &config)
+
+	return &cleanCmd
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"dbaker/pkg/action"
+	"dbaker/pkg/config"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultConfigFilePath = config.DefaultFilePath
+
+// bindConnectionFlags binds the database connection flags shared by the commands. A DSN (--dsn or DATABASE_URL)
+// takes the settings which are not given as flags, otherwise they are taken from the selected profile of the
+// config file, and finally by pgx from the PG* environment variables, the service file and ~/.pgpass.
+func bindConnectionFlags(cmd *cobra.Command, config *config.Config) {
+	var configFilePath, profileName string
+
+	cmd.Flags().StringVar(&config.DSN, "dsn", "", "connection string, postgres:// URL or keyword/value settings (env DATABASE_URL)")
+	cmd.Flags().StringVar(&config.Service, "service", "", "connection service name of the pg service file")
+	cmd.Flags().StringVarP(&config.Host, "host", "H", "", "host of the db to connect to")
+	cmd.Flags().UintVarP(&config.Port, "port", "P", 0, "port of the db to connect to (default 5432)")
+	cmd.Flags().StringVarP(&config.Database, "database", "d", "", "database (pg) to connect to")
+	cmd.Flags().StringVarP(&config.Username, "username", "u", "", "database user")
+	cmd.Flags().StringVarP(&config.Password, "password", "p", "", "database user password (prefer ~/.pgpass or PGPASSWORD)")
+	cmd.Flags().StringVar(&config.SSLMode, "sslmode", "", "ssl mode: disable, allow, prefer, require, verify-ca or verify-full (default prefer)")
+	cmd.Flags().StringVar(&config.SSLRootCert, "sslrootcert", "", "certificate authority file to verify the server certificate with")
+	cmd.Flags().StringVar(&config.SSLCert, "sslcert", "", "client certificate file")
+	cmd.Flags().StringVar(&config.SSLKey, "sslkey", "", "client certificate key file")
+	cmd.Flags().StringVar(&configFilePath, "config", defaultConfigFilePath, "config file with connection profiles")
+	cmd.Flags().StringVar(&profileName, "profile", "", "profile of the config file to use (env DBAKER_PROFILE)")
+
+	cmd.PreRunE = func(cmd *cobra.Command, _ []string) error {
+		if profileName == "" {
+			profileName = os.Getenv("DBAKER_PROFILE")
+		}
+
+		// the DSN of the environment wins over the profile as well
+		if config.DSN == "" {
+			config.DSN = os.Getenv("DATABASE_URL")
+		}
+
+		return applyConfigFile(config, configFilePath, profileName, cmd.Flags().Changed("config"))
+	}
+}
+
+// applyConfigFile applies the profile of the config file, a missing default config file is ignored
+// unless a profile is selected
+func applyConfigFile(cfg *config.Config, configFilePath string, profileName string, explicit bool) error {
+	if _, err := os.Stat(configFilePath); errors.Is(err, os.ErrNotExist) && !explicit {
+		if profileName != "" {
+			return fmt.Errorf("profile %s selected, but there is no config file %s", profileName, configFilePath)
+		}
+		return nil
+	}
+
+	file, err := config.ReadFile(configFilePath)
+	if err != nil {
+		return err
+	}
+
+	profile, err := file.Profile(profileName)
+	if err != nil {
+		return err
+	}
+
+	cfg.ApplyProfile(profile)
+	return nil
+}
+
+// bindTargetConnectionFlags binds the connection flags of the database the data is written into,
+// settings which are not given are taken over from the source connection. A required target must
+// name its database, as writing into the source database is most likely a mistake.
+// It has to be bound after the source connection flags.
+func bindTargetConnectionFlags(cmd *cobra.Command, config *config.Config, required bool) {
+	cmd.Flags().StringVar(&config.Target.DSN, "target-dsn", "", "connection string of the target db, other settings are not inherited from the source")
+	cmd.Flags().StringVar(&config.Target.Host, "target-host", "", "host of the target db (defaults to --host)")
+	cmd.Flags().UintVar(&config.Target.Port, "target-port", 0, "port of the target db (defaults to --port)")
+	cmd.Flags().StringVar(&config.Target.Database, "target-database", "", "target database (pg) to write into")
+	cmd.Flags().StringVar(&config.Target.Username, "target-username", "", "target database user (defaults to --username)")
+	cmd.Flags().StringVar(&config.Target.Password, "target-password", "", "target database user password (defaults to --password)")
+	cmd.Flags().StringVar(&config.Target.SSLMode, "target-sslmode", "", "ssl mode of the target db (defaults to --sslmode)")
+
+	if !required {
+		return
+	}
+
+	resolveConnection := cmd.PreRunE
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := resolveConnection(cmd, args); err != nil {
+			return err
+		}
+
+		if config.Target.Database == "" && config.Target.DSN == "" {
+			return errors.New("target database is required, use --target-database, --target-dsn or a profile target")
+		}
+		return nil
+	}
+}
+
+// bindRecipeFlag binds the path of the recipe the command reads or writes
+func bindRecipeFlag(cmd *cobra.Command, config *config.Config) {
+	cmd.Flags().StringVar(&config.RecipePath, "recipe", "", "recipe file or directory (defaults to ./<database>.recipe.json)")
+}
+
+// bindTransactionFlags binds the flags of the transactions generated rows are written in
+func bindTransactionFlags(cmd *cobra.Command, config *config.Config) {
+	cmd.Flags().StringVar((*string)(&config.Transaction), "tx", "none", "transaction scope: none, run (all or nothing), table (each table all or nothing) or batch (commit every batch)")
+	cmd.Flags().Uint32Var(&config.BatchSize, "batch-size", 1000, "rows between savepoints within transactions, or per transaction in batch mode")
+	cmd.Flags().BoolVar(&config.DryRun, "dry-run", false, "write everything in a transaction which is always rolled back, reports the constraint violations of every table")
+}
+
+// bindCleanFlags binds the flags removing the data of previous runs before generating
+func bindCleanFlags(cmd *cobra.Command, config *config.Config) {
+	cmd.Flags().BoolVar(&config.Truncate, "truncate", false, "truncate the recipe tables first (restart identity cascade)")
+	cmd.Flags().BoolVar(&config.DeleteGenerated, "delete-generated", false, "delete the rows generated by a previous run of the same iterations first (by tag column or unique values)")
+	cmd.Flags().StringVar(&config.Tag, "tag", action.DefaultTag, "value written into the tag columns of the recipe tables")
+
+	cmd.MarkFlagsMutuallyExclusive("truncate", "delete-generated")
+}
+
+// bindUniqueFlags binds the flags of the unique values, cleaning up has to generate the same values as the run
+func bindUniqueFlags(cmd *cobra.Command, config *config.Config) {
+	cmd.Flags().BoolVar(&config.Scramble, "scramble", false, "scramble the order of unique values, so that they aren't sequential (same values, different rows)")
+	cmd.Flags().Uint64Var(&config.Seed, "seed", 0, "seed of the scrambled unique values")
+}
+
+// bindStateFlags binds the flags of resuming runs and appending rows, the iterFrom and unique flags have to be bound before
+func bindStateFlags(cmd *cobra.Command, config *config.Config) {
+	cmd.Flags().StringVar(&config.StatePath, "state", "", "file recording the progress of the run, rerunning with it resumes an interrupted run")
+	cmd.Flags().BoolVar(&config.AutoIter, "auto-iter", false, "start from the iteration following the highest unique values in the database, to append rows")
+
+	cmd.MarkFlagsMutuallyExclusive("iterFrom", "auto-iter")
+	// scrambled values don't tell the iteration they were generated on
+	cmd.MarkFlagsMutuallyExclusive("scramble", "auto-iter")
+}
+
+// bindOutputFlags binds the flags of the progress output and the summary of the run, quiet and verbose
+// override the log level. It has to be bound after the connection flags.
+func bindOutputFlags(cmd *cobra.Command, config *config.Config) {
+	cmd.Flags().BoolVarP(&config.Quiet, "quiet", "q", false, "no progress and messages, warnings and errors only")
+	cmd.Flags().BoolVarP(&config.Verbose, "verbose", "v", false, "debug logs, including every written query and its values")
+	cmd.Flags().StringVar(&config.SummaryPath, "summary", "", "write a JSON summary of the run into the file, - for stdout")
+
+	cmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+
+	resolveConnection := cmd.PreRunE
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		switch {
+		case config.Quiet:
+			logLevel.Set(slog.LevelWarn)
+		case config.Verbose:
+			logLevel.Set(slog.LevelDebug)
+		}
+
+		return resolveConnection(cmd, args)
+	}
+}
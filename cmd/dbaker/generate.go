@@ -14,28 +14,26 @@ func newGenerateCommand() *cobra.Command {
 		Use:     "generate",
 		Aliases: []string{"g"},
 		Short:   "Generate fake data",
-		Long:    "Generate fake data and write them directly into the live database instance",
+		Long:    "Generate fake data and write them directly into the live database instance, or into the target database when given (e.g. a freshly migrated one), the recipe is looked up by the source database",
 		RunE: func(_ *cobra.Command, _ []string) error {
-			pgAdapter := adapter.NewPostgreSQLAdapter(config)
+			pgAdapter := adapter.NewPostgreSQLAdapter(config.TargetConfig())
 			action := action.NewGenerate(config, pgAdapter)
 
 			return action.Execute()
 		},
 	}
 
-	introspectCmd.Flags().StringVarP(&config.Host, "host", "H", "", "host of the db to introspect")
-	introspectCmd.Flags().UintVarP(&config.Port, "port", "P", 5432, "port of the db to introspect")
-	introspectCmd.Flags().StringVarP(&config.Database, "database", "d", "", "database (pg) to connect to")
-	introspectCmd.Flags().StringVarP(&config.Username, "username", "u", "", "database user")
-	introspectCmd.Flags().StringVarP(&config.Password, "password", "p", "", "database user")
-	introspectCmd.Flags().Uint32VarP(&config.DataSize, "size", "s", 0, "dataset size, number of rows to generate")
+	bindConnectionFlags(&introspectCmd, &config)
+	bindRecipeFlag(&introspectCmd, &config)
+	bindTargetConnectionFlags(&introspectCmd, &config, false)
+	bindTransactionFlags(&introspectCmd, &config)
+	bindCleanFlags(&introspectCmd, &config)
+	introspectCmd.Flags().Uint32VarP(&config.DataSize, "size", "s", 0, "dataset size, number of rows to generate for tables without rowCount in the recipe")
 	introspectCmd.Flags().Uint32VarP(&config.IterFrom, "iterFrom", "i", 0, "iteration index from which to start generating unique values")
-
-	introspectCmd.MarkFlagRequired("host")
-	introspectCmd.MarkFlagRequired("database")
-	introspectCmd.MarkFlagRequired("username")
-	introspectCmd.MarkFlagRequired("password")
-	introspectCmd.MarkFlagRequired("size")
+	introspectCmd.Flags().Float64Var(&config.NullRatio, "null-ratio", 0, "default probability (0-1) of generating NULL for nullable columns")
+	bindUniqueFlags(&introspectCmd, &config)
+	bindStateFlags(&introspectCmd, &config)
+	bindOutputFlags(&introspectCmd, &config)
 
 	return &introspectCmd
 }
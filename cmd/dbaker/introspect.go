@@ -23,17 +23,13 @@ func newIntrospecCommand() *cobra.Command {
 		},
 	}
 
-	introspectCmd.Flags().StringVarP(&config.Host, "host", "H", "", "host of the db to introspect")
-	introspectCmd.Flags().UintVarP(&config.Port, "port", "P", 5432, "port of the db to introspect")
-	introspectCmd.Flags().StringVarP(&config.Database, "database", "d", "", "database (pg) to connect to")
-	introspectCmd.Flags().StringVarP(&config.Username, "username", "u", "", "database user")
-	introspectCmd.Flags().StringVarP(&config.Password, "password", "p", "", "database user")
+	bindConnectionFlags(&introspectCmd, &config)
+	bindRecipeFlag(&introspectCmd, &config)
 	introspectCmd.Flags().StringArrayVarP(&config.Tables, "tables", "t", []string{}, "tables to include in the introspection")
 
-	introspectCmd.MarkFlagRequired("host")
-	introspectCmd.MarkFlagRequired("database")
-	introspectCmd.MarkFlagRequired("username")
-	introspectCmd.MarkFlagRequired("password")
+	introspectCmd.Flags().BoolVarP(&config.Merge, "merge", "m", false, "merge into the existing recipe keeping annotations, row counts, distributions and other edits")
+	introspectCmd.Flags().BoolVar(&config.Force, "force", false, "merge into YAML and TOML recipes holding comments, which are lost")
+
 	introspectCmd.MarkFlagRequired("tables")
 
 	return &introspectCmd
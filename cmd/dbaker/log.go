@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// logLevel is the level of the default logger, commands with output flags adjust it
+var logLevel slog.LevelVar
+
+// bindLogFlags binds the flags of the logs written to stderr by all commands
+func bindLogFlags(cmd *cobra.Command) {
+	var level, format string
+
+	cmd.PersistentFlags().StringVar(&level, "log-level", "info", "log level: debug, info, warn or error")
+	cmd.PersistentFlags().StringVar(&format, "log-format", "text", "log format: text or json")
+
+	cmd.PersistentPreRunE = func(_ *cobra.Command, _ []string) error {
+		if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+			return fmt.Errorf("invalid log level %s: %w", level, err)
+		}
+
+		options := &slog.HandlerOptions{Level: &logLevel}
+		switch format {
+		case "text":
+			// the time is noise on a terminal, json logs keep it for log collectors
+			options.ReplaceAttr = func(groups []string, attr slog.Attr) slog.Attr {
+				if attr.Key == slog.TimeKey && len(groups) == 0 {
+					return slog.Attr{}
+				}
+				return attr
+			}
+			slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, options)))
+		case "json":
+			slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, options)))
+		default:
+			return fmt.Errorf("invalid log format %s, expected text or json", format)
+		}
+
+		return nil
+	}
+}
@@ -1,6 +1,10 @@
 package main
 
-import "github.com/spf13/cobra"
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
 
 func main() {
 	dbakerCommand := cobra.Command{
@@ -8,7 +12,11 @@ func main() {
 		Short: "Fake data generator (DB + Faker = DBaker)",
 		Long:  "Introspect live database instance, generate & write fake data right back into the instance",
 	}
-	dbakerCommand.AddCommand(newIntrospecCommand(), newGenerateCommand())
+	bindLogFlags(&dbakerCommand)
+	dbakerCommand.AddCommand(newIntrospecCommand(), newProfileCommand(), newGenerateCommand(), newBakeCommand(), newCleanCommand(), newMaskCommand(), newSubsetCommand(), newRecipeCommand())
 
-	dbakerCommand.Execute()
+	// cobra prints the error, the exit code lets scripts and CI detect the failure
+	if err := dbakerCommand.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"dbaker/pkg/action"
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newMaskCommand() *cobra.Command {
+	var config config.Config
+	maskCmd := cobra.Command{
+		Use:     "mask",
+		Aliases: []string{"m"},
+		Short:   "Copy masked data into another database",
+		Long:    "Read rows of the recipe tables from the source database and write them into the target database, masking values per column (keep, fake, hash, format, null)",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if config.MaskKey == "" {
+				config.MaskKey = os.Getenv("DBAKER_MASK_KEY")
+			}
+
+			sourceAdapter := adapter.NewPostgreSQLAdapter(config)
+			targetAdapter := adapter.NewPostgreSQLAdapter(config.TargetConfig())
+			action := action.NewMask(config, sourceAdapter, targetAdapter)
+
+			return action.Execute()
+		},
+	}
+
+	bindConnectionFlags(&maskCmd, &config)
+	bindRecipeFlag(&maskCmd, &config)
+	bindTargetConnectionFlags(&maskCmd, &config, true)
+	maskCmd.Flags().StringVar(&config.MaskKey, "mask-key", "", "secret key of hash and format masks, keeps pseudonyms stable across runs (env DBAKER_MASK_KEY)")
+	maskCmd.Flags().Uint32VarP(&config.IterFrom, "iterFrom", "i", 0, "iteration index from which to start generating unique fake values")
+
+	return &maskCmd
+}
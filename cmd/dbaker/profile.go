@@ -0,0 +1,35 @@
+package main
+
+import (
+	"dbaker/pkg/action"
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+
+	"github.com/spf13/cobra"
+)
+
+func newProfileCommand() *cobra.Command {
+	var config config.Config
+	profileCmd := cobra.Command{
+		Use:     "profile",
+		Aliases: []string{"p"},
+		Short:   "Profile database data for data gen.",
+		Long:    "Introspect a live database instance, sample statistics of its data (row counts, null fractions, distributions) and create intermediate representation for data gen.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			pgAdapter := adapter.NewPostgreSQLAdapter(config)
+			action := action.NewProfile(config, pgAdapter)
+
+			return action.Execute()
+		},
+	}
+
+	bindConnectionFlags(&profileCmd, &config)
+	bindRecipeFlag(&profileCmd, &config)
+	profileCmd.Flags().StringArrayVarP(&config.Tables, "tables", "t", []string{}, "tables to include in the profiling")
+
+	profileCmd.Flags().BoolVar(&config.ExactStats, "exact-stats", false, "keep the exact min/max, histogram bounds and most common values, which are values of the profiled data, instead of rounding them")
+
+	profileCmd.MarkFlagRequired("tables")
+
+	return &profileCmd
+}
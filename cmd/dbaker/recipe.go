@@ -0,0 +1,103 @@
+package main
+
+import (
+	"dbaker/pkg/action"
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+
+	"github.com/spf13/cobra"
+)
+
+func newRecipeCommand() *cobra.Command {
+	recipeCmd := cobra.Command{
+		Use:     "recipe",
+		Aliases: []string{"r"},
+		Short:   "Work with recipe files",
+	}
+	recipeCmd.AddCommand(newRecipeValidateCommand(), newRecipeUpgradeCommand(), newRecipeDiffCommand(), newRecipeConvertCommand())
+
+	return &recipeCmd
+}
+
+func newRecipeValidateCommand() *cobra.Command {
+	var config config.Config
+	validateCmd := cobra.Command{
+		Use:   "validate <recipe file>...",
+		Short: "Validate recipe files",
+		Long:  "Validate recipe files against the recipe JSON Schema and check column annotations, distributions, null ratios and masks, exits non-zero when any recipe is invalid",
+		Args:  cobra.MinimumNArgs(1),
+		// validation problems are the output, usage would only bury them
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			action := action.NewRecipeValidate(config, args)
+
+			return action.Execute()
+		},
+	}
+
+	return &validateCmd
+}
+
+func newRecipeUpgradeCommand() *cobra.Command {
+	var config config.Config
+	upgradeCmd := cobra.Command{
+		Use:   "upgrade <recipe file>...",
+		Short: "Upgrade recipe files to the current version",
+		Long:  "Rewrite recipe files written by older dbaker versions in place in the current recipe version, recipes are upgraded on read anyway, upgrading keeps committed recipes current",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			action := action.NewRecipeUpgrade(config, args)
+
+			return action.Execute()
+		},
+	}
+
+	upgradeCmd.Flags().BoolVar(&config.Force, "force", false, "upgrade YAML and TOML recipes holding comments, which are lost")
+
+	return &upgradeCmd
+}
+
+func newRecipeDiffCommand() *cobra.Command {
+	var config config.Config
+	diffCmd := cobra.Command{
+		Use:   "diff [recipe file]",
+		Short: "Compare a recipe with the live database schema",
+		Long:  "Compare the recipe tables with the live database schema (columns, types, nullability, uniqueness, foreign keys, check constraints), exits non-zero when they diverge, the recipe defaults to --recipe or ./<database>.recipe.json",
+		Args:  cobra.MaximumNArgs(1),
+		// the differences are the output, usage would only bury them
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			recipeFilePath := config.RecipeFilePath()
+			if len(args) > 0 {
+				recipeFilePath = args[0]
+			}
+
+			pgAdapter := adapter.NewPostgreSQLAdapter(config)
+			action := action.NewRecipeDiff(config, pgAdapter, recipeFilePath)
+
+			return action.Execute()
+		},
+	}
+
+	bindConnectionFlags(&diffCmd, &config)
+	bindRecipeFlag(&diffCmd, &config)
+
+	return &diffCmd
+}
+
+func newRecipeConvertCommand() *cobra.Command {
+	var config config.Config
+	convertCmd := cobra.Command{
+		Use:   "convert <from recipe file> <to recipe file>",
+		Short: "Convert a recipe into another format",
+		Long:  "Convert a recipe between JSON, YAML (.yaml, .yml) and TOML (.toml), formats are given by the file extensions",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			action := action.NewRecipeConvert(config, args[0], args[1])
+
+			return action.Execute()
+		},
+	}
+
+	return &convertCmd
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"dbaker/pkg/action"
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newSubsetCommand() *cobra.Command {
+	var config config.Config
+	subsetCmd := cobra.Command{
+		Use:     "subset",
+		Aliases: []string{"s"},
+		Short:   "Copy a referentially complete slice of data into another database",
+		Long:    "Pick rows of a root table (a sample or a key query) and copy them into the target database along with all rows they reference or are referenced by, optionally masked",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if config.MaskKey == "" {
+				config.MaskKey = os.Getenv("DBAKER_MASK_KEY")
+			}
+
+			sourceAdapter := adapter.NewPostgreSQLAdapter(config)
+			targetAdapter := adapter.NewPostgreSQLAdapter(config.TargetConfig())
+			action := action.NewSubset(config, sourceAdapter, targetAdapter)
+
+			return action.Execute()
+		},
+	}
+
+	bindConnectionFlags(&subsetCmd, &config)
+	bindRecipeFlag(&subsetCmd, &config)
+	bindTargetConnectionFlags(&subsetCmd, &config, true)
+	subsetCmd.Flags().StringVarP(&config.SubsetRoot, "root", "r", "", "root table (schema.table) the subset starts from")
+	subsetCmd.Flags().Float64Var(&config.SubsetPercent, "percent", 0, "percentage of root table rows to pick")
+	subsetCmd.Flags().StringVarP(&config.SubsetQuery, "query", "q", "", "query selecting a key column of the root rows, e.g. \"select id from customers where region = 'EU'\"")
+	subsetCmd.Flags().BoolVar(&config.Mask, "mask", false, "mask the copied rows by the column masks of the recipe")
+	subsetCmd.Flags().StringVar(&config.MaskKey, "mask-key", "", "secret key of hash and format masks, keeps pseudonyms stable across runs (env DBAKER_MASK_KEY)")
+	subsetCmd.Flags().Uint32VarP(&config.IterFrom, "iterFrom", "i", 0, "iteration index from which to start generating unique fake values")
+
+	subsetCmd.MarkFlagRequired("root")
+	subsetCmd.MarkFlagsMutuallyExclusive("percent", "query")
+	subsetCmd.MarkFlagsOneRequired("percent", "query")
+
+	return &subsetCmd
+}
@@ -0,0 +1,134 @@
+package action
+
+import (
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+	"dbaker/pkg/model"
+	"dbaker/pkg/recipe"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+type bake struct {
+	config   config.Config
+	adapter  adapter.PostgreSQLAdapter
+	generate *generate
+}
+
+func NewBake(config config.Config, sourceAdapter adapter.PostgreSQLAdapter, targetAdapter adapter.PostgreSQLAdapter) *bake {
+	return &bake{
+		config,
+		sourceAdapter,
+		NewGenerate(config, targetAdapter),
+	}
+}
+
+// Execute introspects the tables, applies the overlay recipe and generates the rows right away,
+// the recipe is kept in memory unless it should be saved
+func (b *bake) Execute() error {
+	if err := b.adapter.Init(); err != nil {
+		return err
+	}
+	defer b.adapter.Close()
+
+	tables, err := introspectTables(&b.adapter, b.config.Tables)
+	if err != nil {
+		return err
+	}
+
+	if b.config.OverlayPath != "" {
+		if tables, err = overlayRecipe(b.config.OverlayPath, tables); err != nil {
+			return err
+		}
+	}
+
+	if b.config.SaveRecipe {
+		recipeFilePath := b.config.RecipeFilePath()
+		if err := recipe.Write(recipeFilePath, tables); err != nil {
+			return err
+		}
+		slog.Info("recipe written", "path", recipeFilePath)
+	}
+
+	if err := b.generate.adapter.Init(); err != nil {
+		return err
+	}
+	defer b.generate.adapter.Close()
+
+	baked := make([]model.Table, len(tables))
+	for index, table := range tables {
+		baked[index] = *table
+	}
+
+	return b.generate.populate(baked)
+}
+
+// overlayRecipe applies the user authored settings of the overlay recipe onto the introspected tables,
+// the overlay only needs to list the tables and columns it overrides. Overlay tables which were not
+// introspected and columns which don't exist are reported and ignored.
+func overlayRecipe(overlayFilePath string, tables []*model.Table) ([]*model.Table, error) {
+	overlay, err := recipe.ReadOverlay(overlayFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the overlay recipe: %w", err)
+	}
+
+	if err := resolveOverlaySchemas(overlay, tables); err != nil {
+		return nil, err
+	}
+
+	merged, changes := recipe.Merge(overlay, tables)
+	for _, change := range changes {
+		if change.Kind == recipe.Removed {
+			slog.Warn("overlay column does not exist, ignored", "table", change.Table, "column", change.Column)
+		}
+	}
+
+	// keep the introspection order, it is the order the tables are generated in
+	var baked []*model.Table
+	for _, table := range tables {
+		baked = append(baked, merged[slices.IndexFunc(merged, func(mergedTable *model.Table) bool { return sameTable(mergedTable, table) })])
+	}
+
+	for _, table := range overlay {
+		if !slices.ContainsFunc(tables, func(introspected *model.Table) bool { return sameTable(&table, introspected) }) {
+			slog.Warn("overlay table is not baked, ignored", "table", table.Schema+"."+table.Name)
+		}
+	}
+
+	return baked, nil
+}
+
+// resolveOverlaySchemas sets the schema of overlay tables without one: the schema of the introspected table
+// of the name, public when there is none. A name introspected in several schemas is an error.
+func resolveOverlaySchemas(overlay []model.Table, tables []*model.Table) error {
+	for index := range overlay {
+		table := &overlay[index]
+		if table.Schema != "" {
+			continue
+		}
+
+		var schemas []string
+		for _, introspected := range tables {
+			if introspected.Name == table.Name {
+				schemas = append(schemas, introspected.Schema)
+			}
+		}
+
+		switch len(schemas) {
+		case 0:
+			table.Schema = "public"
+		case 1:
+			table.Schema = schemas[0]
+		default:
+			return fmt.Errorf("overlay table '%s' is baked in schemas %s, set its tableSchema", table.Name, strings.Join(schemas, ", "))
+		}
+	}
+
+	return nil
+}
+
+func sameTable(a *model.Table, b *model.Table) bool {
+	return a.Name == b.Name && a.Schema == b.Schema
+}
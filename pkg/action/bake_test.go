@@ -0,0 +1,116 @@
+package action
+
+import (
+	"dbaker/pkg/model"
+	"dbaker/pkg/recipe"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOverlayRecipe(t *testing.T) {
+	overlayFilePath := filepath.Join(t.TempDir(), "overlay.recipe.yaml")
+	overlay := `
+version: 2
+tables:
+  - tableName: users
+    tableSchema: public
+    rowCount: 50
+    tableColumns:
+      - { columnName: role, annotation: "oneOf(admin,viewer)" }
+      - { columnName: dropped, nullRatio: 0.5 }
+  - { tableName: audit, tableSchema: public, rowCount: 10 }
+`
+	if err := os.WriteFile(overlayFilePath, []byte(overlay), 0644); err != nil {
+		t.Fatalf("failed to write overlay: %v", err)
+	}
+
+	tables := []*model.Table{
+		{Name: "groups", Schema: "public", Columns: []model.Column{{Name: "id", Typ: model.Int}}},
+		{Name: "users", Schema: "public", Columns: []model.Column{
+			{Name: "id", Typ: model.Int},
+			{Name: "email", Typ: model.Varchar, Annotation: "email"},
+			{Name: "role", Typ: model.Varchar, MaxLength: 20, Annotation: "jobTitle"},
+		}},
+	}
+
+	baked, err := overlayRecipe(overlayFilePath, tables)
+	if err != nil {
+		t.Fatalf("overlayRecipe() unexpected error: %v", err)
+	}
+
+	if len(baked) != 2 || baked[0].Name != "groups" || baked[1].Name != "users" {
+		t.Fatalf("overlayRecipe() = %+v; want the introspected tables in order", baked)
+	}
+
+	users := baked[1]
+	if users.RowCount != 50 {
+		t.Errorf("users row count = %d; want 50", users.RowCount)
+	}
+	if len(users.Columns) != 3 {
+		t.Fatalf("users columns = %+v; want the introspected columns", users.Columns)
+	}
+	if users.Columns[1].Annotation != "email" {
+		t.Errorf("email annotation = %s; want the inferred email", users.Columns[1].Annotation)
+	}
+	if users.Columns[2].Annotation != "oneOf(admin,viewer)" || users.Columns[2].MaxLength != 20 {
+		t.Errorf("role column = %+v; want the overlay annotation on the introspected column", users.Columns[2])
+	}
+}
+
+func TestOverlayRecipeRejectsInvalidSettings(t *testing.T) {
+	overlayFilePath := filepath.Join(t.TempDir(), "overlay.recipe.yaml")
+	overlay := `
+version: 2
+tables:
+  - tableName: users
+    tableColumns:
+      - { columnName: role, anotation: "oneOf(admin,viewer)" }
+      - { nullRatio: 0.5 }
+`
+	if err := os.WriteFile(overlayFilePath, []byte(overlay), 0644); err != nil {
+		t.Fatalf("failed to write overlay: %v", err)
+	}
+
+	_, err := overlayRecipe(overlayFilePath, nil)
+	if !errors.Is(err, recipe.ErrInvalidRecipe) {
+		t.Fatalf("overlayRecipe() error = %v; want %v", err, recipe.ErrInvalidRecipe)
+	}
+	for _, want := range []string{"anotation: unknown property", "missing property 'columnName'"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("overlayRecipe() error = %v; want containing %q", err, want)
+		}
+	}
+}
+
+func TestOverlayRecipeResolvesMissingSchemas(t *testing.T) {
+	overlayFilePath := filepath.Join(t.TempDir(), "overlay.recipe.yaml")
+	overlay := `
+version: 2
+tables:
+  - { tableName: orders, rowCount: 20 }
+  - { tableName: users, rowCount: 50 }
+`
+	if err := os.WriteFile(overlayFilePath, []byte(overlay), 0644); err != nil {
+		t.Fatalf("failed to write overlay: %v", err)
+	}
+
+	tables := []*model.Table{
+		{Name: "users", Schema: "public", Columns: []model.Column{{Name: "id", Typ: model.Int}}},
+		{Name: "orders", Schema: "shop", Columns: []model.Column{{Name: "id", Typ: model.Int}}},
+	}
+	baked, err := overlayRecipe(overlayFilePath, tables)
+	if err != nil {
+		t.Fatalf("overlayRecipe() unexpected error: %v", err)
+	}
+	if baked[0].RowCount != 50 || baked[1].RowCount != 20 {
+		t.Errorf("row counts = %d, %d; want the overlay ones, 50 and 20", baked[0].RowCount, baked[1].RowCount)
+	}
+
+	tables = append(tables, &model.Table{Name: "orders", Schema: "archive", Columns: []model.Column{{Name: "id", Typ: model.Int}}})
+	if _, err := overlayRecipe(overlayFilePath, tables); err == nil || !strings.Contains(err.Error(), "shop, archive") {
+		t.Errorf("overlayRecipe() error = %v; want orders of schemas shop and archive reported", err)
+	}
+}
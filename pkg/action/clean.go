@@ -0,0 +1,211 @@
+package action
+
+import (
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+	"dbaker/pkg/generator"
+	"dbaker/pkg/model"
+	"dbaker/pkg/recipe"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// DefaultTag is written into the tag columns when no other tag is given
+const DefaultTag = "dbaker"
+
+type clean struct {
+	config  config.Config
+	adapter adapter.PostgreSQLAdapter
+	gen     *generator.ValueGenerator
+}
+
+func NewClean(config config.Config, adapter adapter.PostgreSQLAdapter) *clean {
+	return &clean{
+		config,
+		adapter,
+		generator.NewValueGenerator(config),
+	}
+}
+
+// Execute removes the generated rows of the recipe tables, or all their rows when truncating
+func (c *clean) Execute() error {
+	recipeFilePath := c.config.RecipeFilePath()
+	tables, err := recipe.Read(recipeFilePath)
+	if err != nil {
+		return err
+	}
+
+	if err := c.adapter.Init(); err != nil {
+		return err
+	}
+	defer c.adapter.Close()
+
+	if !c.config.Truncate {
+		c.config.DeleteGenerated = true
+	}
+
+	// the tables are cleaned all or nothing
+	if err := c.adapter.Begin(); err != nil {
+		return err
+	}
+	if err := cleanTables(&c.adapter, c.gen, c.config, tables); err != nil {
+		return errors.Join(err, c.adapter.Rollback())
+	}
+	if err := c.adapter.Commit(); err != nil {
+		return err
+	}
+
+	slog.Info("database was cleaned successfully")
+
+	return nil
+}
+
+// cleanTables removes the data of previous runs: all rows of the tables (truncate) or the generated rows only,
+// found by the tag column or by the unique values of the iteration range. Tables whose generated rows
+// can't be found are reported before anything is deleted.
+func cleanTables(adapter *adapter.PostgreSQLAdapter, gen *generator.ValueGenerator, config config.Config, tables []model.Table) error {
+	if config.Truncate {
+		slog.Info("truncating tables", "tables", len(tables))
+		return adapter.TruncateTables(tables)
+	}
+	if !config.DeleteGenerated {
+		return nil
+	}
+
+	type deletion struct {
+		column model.Column
+		values []string
+	}
+
+	var deletions []deletion
+	var errs []error
+	for _, table := range tables {
+		column, values, err := generatedKeys(gen, config, table)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		deletions = append(deletions, deletion{column, values})
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	order, err := childFirstOrder(tables)
+	if err != nil {
+		return err
+	}
+
+	for _, index := range order {
+		table := tables[index]
+		slog.Info("deleting generated rows", "table", table.WriteSchema()+"."+table.Name)
+
+		deleted, err := adapter.DeleteRowsByKey(table, deletions[index].column, deletions[index].values)
+		if err != nil {
+			return err
+		}
+		slog.Info("generated rows deleted", "table", table.WriteSchema()+"."+table.Name, "rows", deleted)
+	}
+
+	return nil
+}
+
+// childFirstOrder returns the indexes of the tables with the referencing tables before the referenced ones,
+// so that their rows are deleted first
+func childFirstOrder(tables []model.Table) ([]int, error) {
+	edges, _ := foreignKeyEdges(tables)
+	order, err := parentFirstOrder(tables, edges)
+	if err != nil {
+		return nil, fmt.Errorf("generated rows can't be deleted, truncate instead: %w", err)
+	}
+	slices.Reverse(order)
+	return order, nil
+}
+
+// generatedKeys returns the column and its values identifying the generated rows of the table, the tag
+// column holding the tag, otherwise a unique column whose values follow from the iteration alone
+func generatedKeys(gen *generator.ValueGenerator, config config.Config, table model.Table) (model.Column, []string, error) {
+	if table.TagColumn != "" {
+		index := slices.IndexFunc(table.Columns, func(column model.Column) bool { return column.Name == table.TagColumn })
+		if index < 0 {
+			return model.Column{}, nil, fmt.Errorf("table '%s.%s' has no tag column '%s'", table.Schema, table.Name, table.TagColumn)
+		}
+		return table.Columns[index], []string{tagOf(config)}, nil
+	}
+
+	index := slices.IndexFunc(table.Columns, isIterationKey)
+	if index < 0 {
+		return model.Column{}, nil, fmt.Errorf("generated rows of table '%s.%s' can't be told apart, set a tagColumn in the recipe or truncate", table.Schema, table.Name)
+	}
+
+	column := table.Columns[index]
+	rowCount := rowCountOf(table, config)
+	if rowCount == 0 {
+		return model.Column{}, nil, fmt.Errorf("no row count for table '%s.%s', set rowCount in the recipe or the dataset size", table.Schema, table.Name)
+	}
+
+	values := make([]string, rowCount)
+	for iter := range rowCount {
+		value, err := gen.GenUniqueVal(table.Schema+"."+table.Name, column, config.IterFrom+iter)
+		if err != nil {
+			return model.Column{}, nil, err
+		}
+		values[iter] = fmt.Sprint(value)
+	}
+
+	return column, values, nil
+}
+
+// isIterationKey reports whether the unique values of the column follow from the iteration alone,
+// nullable columns may hold NULL instead and text values are partly random unless semantic (e.g. email)
+func isIterationKey(column model.Column) bool {
+	if !column.IsUnique || column.IsNullable || column.IsGenerated {
+		return false
+	}
+	if generator.HasUniqueGenerator(column.Annotation) {
+		return true
+	}
+
+	return slices.Contains([]model.ColumnType{
+		model.SmallInt, model.Int, model.BigInt, model.Real, model.Double, model.UUID, model.Date, model.Timestamp, model.TimestampTZ,
+	}, column.Typ)
+}
+
+// validateTagColumns checks the tag columns can hold the tag
+func validateTagColumns(tables []model.Table, config config.Config) error {
+	var errs []error
+	for _, table := range tables {
+		if table.TagColumn == "" {
+			continue
+		}
+
+		index := slices.IndexFunc(table.Columns, func(column model.Column) bool { return column.Name == table.TagColumn })
+		switch {
+		case index < 0:
+			errs = append(errs, fmt.Errorf("table '%s.%s' has no tag column '%s'", table.Schema, table.Name, table.TagColumn))
+		case !slices.Contains([]model.ColumnType{model.Char, model.Varchar, model.Text}, table.Columns[index].Typ) || table.Columns[index].IsGenerated:
+			errs = append(errs, fmt.Errorf("tag column '%s' of table '%s.%s' is not a writable text column", table.TagColumn, table.Schema, table.Name))
+		case table.Columns[index].MaxLength > 0 && uint(len(tagOf(config))) > table.Columns[index].MaxLength:
+			errs = append(errs, fmt.Errorf("tag '%s' is longer than the tag column '%s' of table '%s.%s'", tagOf(config), table.TagColumn, table.Schema, table.Name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func tagOf(config config.Config) string {
+	if config.Tag == "" {
+		return DefaultTag
+	}
+	return config.Tag
+}
+
+// rowCountOf returns the number of rows generated into the table
+func rowCountOf(table model.Table, config config.Config) uint32 {
+	if table.RowCount > 0 {
+		return table.RowCount
+	}
+	return config.DataSize
+}
@@ -0,0 +1,127 @@
+package action
+
+import (
+	"dbaker/pkg/config"
+	"dbaker/pkg/generator"
+	"dbaker/pkg/model"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedKeys(t *testing.T) {
+	testCases := []struct {
+		name       string
+		table      model.Table
+		config     config.Config
+		wantColumn string
+		wantValues []string
+		wantErr    string
+	}{
+		{
+			name: "tag column",
+			table: model.Table{Name: "users", Schema: "public", TagColumn: "seed", Columns: []model.Column{
+				{Name: "id", Typ: model.Int, IsUnique: true},
+				{Name: "seed", Typ: model.Varchar, MaxLength: 20},
+			}},
+			config:     config.Config{Tag: "ci-42"},
+			wantColumn: "seed",
+			wantValues: []string{"ci-42"},
+		},
+		{
+			name: "unique values of the iteration range",
+			table: model.Table{Name: "users", Schema: "public", RowCount: 3, Columns: []model.Column{
+				{Name: "id", Typ: model.Int, IsUnique: true, IsGenerated: true},
+				{Name: "email", Typ: model.Varchar, IsUnique: true},
+				{Name: "number", Typ: model.Int, IsUnique: true, Constraints: []model.Constraint{{Op: model.OpGt, Value: "100"}}},
+			}},
+			config:     config.Config{IterFrom: 10},
+			wantColumn: "number",
+			wantValues: []string{"111", "112", "113"},
+		},
+		{
+			name: "dates of the dataset size",
+			table: model.Table{Name: "days", Schema: "public", Columns: []model.Column{
+				{Name: "day", Typ: model.Date, IsUnique: true},
+			}},
+			config:     config.Config{DataSize: 2},
+			wantColumn: "day",
+			wantValues: []string{"2000-01-01", "2000-01-02"},
+		},
+		{
+			name: "no key",
+			table: model.Table{Name: "notes", Schema: "public", RowCount: 3, Columns: []model.Column{
+				{Name: "id", Typ: model.UUID, IsUnique: true, IsNullable: true},
+				{Name: "code", Typ: model.Varchar, IsUnique: true},
+			}},
+			wantErr: "can't be told apart",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			column, values, err := generatedKeys(generator.NewValueGenerator(tc.config), tc.config, tc.table)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("generatedKeys() error = %v; want containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("generatedKeys() unexpected error: %v", err)
+			}
+
+			if column.Name != tc.wantColumn || !slices.Equal(values, tc.wantValues) {
+				t.Errorf("generatedKeys() = %s %v; want %s %v", column.Name, values, tc.wantColumn, tc.wantValues)
+			}
+		})
+	}
+}
+
+func TestValidateTagColumns(t *testing.T) {
+	tables := []model.Table{
+		{Name: "ok", Schema: "public", TagColumn: "seed", Columns: []model.Column{{Name: "seed", Typ: model.Text}}},
+		{Name: "missing", Schema: "public", TagColumn: "seed", Columns: []model.Column{}},
+		{Name: "numeric", Schema: "public", TagColumn: "seed", Columns: []model.Column{{Name: "seed", Typ: model.Int}}},
+		{Name: "short", Schema: "public", TagColumn: "seed", Columns: []model.Column{{Name: "seed", Typ: model.Varchar, MaxLength: 3}}},
+	}
+
+	err := validateTagColumns(tables, config.Config{})
+	if err == nil {
+		t.Fatalf("validateTagColumns() expected errors")
+	}
+
+	for _, want := range []string{"'public.missing' has no tag column", "of table 'public.numeric' is not a writable text column", "longer than the tag column 'seed' of table 'public.short'"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("validateTagColumns() error = %v; want containing %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "public.ok") {
+		t.Errorf("validateTagColumns() error = %v; want no error of public.ok", err)
+	}
+}
+
+func TestChildFirstOrder(t *testing.T) {
+	// the recipe lists the referencing table first
+	tables := []model.Table{
+		{Name: "orders", Schema: "public", Columns: []model.Column{{Name: "user_id", ForeignKey: "public.users.id"}}},
+		{Name: "users", Schema: "public", Columns: []model.Column{{Name: "id"}, {Name: "team_id", ForeignKey: "public.teams.id"}}},
+		{Name: "teams", Schema: "public", Columns: []model.Column{{Name: "id"}}},
+	}
+
+	order, err := childFirstOrder(tables)
+	if err != nil {
+		t.Fatalf("childFirstOrder() unexpected error: %v", err)
+	}
+	if expected := []int{0, 1, 2}; !slices.Equal(order, expected) {
+		t.Errorf("childFirstOrder() = %v; want %v", order, expected)
+	}
+
+	cyclic := []model.Table{
+		{Name: "a", Schema: "public", Columns: []model.Column{{Name: "b_id", ForeignKey: "public.b.id"}}},
+		{Name: "b", Schema: "public", Columns: []model.Column{{Name: "a_id", ForeignKey: "public.a.id"}}},
+	}
+	if _, err := childFirstOrder(cyclic); err == nil || !strings.Contains(err.Error(), "truncate instead") {
+		t.Errorf("childFirstOrder() error = %v; want a cycle to be rejected", err)
+	}
+}
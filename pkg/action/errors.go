@@ -0,0 +1,19 @@
+package action
+
+import "fmt"
+
+// RowError reports the row of a table which failed to be generated or written, by the iteration it was
+// generated (or copied) on
+type RowError struct {
+	Table string
+	Iter  uint32
+	Err   error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("table '%s', iteration %d: %s", e.Table, e.Iter, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
@@ -3,21 +3,36 @@ package action
 import (
 	"dbaker/pkg/adapter"
 	"dbaker/pkg/config"
+	"dbaker/pkg/generator"
 	"dbaker/pkg/model"
-	"encoding/json"
+	"dbaker/pkg/progress"
+	"dbaker/pkg/recipe"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"slices"
 )
 
 type generate struct {
-	config  config.Config
-	adapter adapter.PostgreSQLAdapter
+	config   config.Config
+	adapter  adapter.PostgreSQLAdapter
+	gen      *generator.ValueGenerator
+	progress *progress.Tracker
 }
 
 func NewGenerate(config config.Config, adapter adapter.PostgreSQLAdapter) *generate {
+	var out io.Writer = os.Stderr
+	if config.Quiet {
+		out = nil
+	}
+
 	return &generate{
 		config,
 		adapter,
+		generator.NewValueGenerator(config),
+		progress.NewTracker(out),
 	}
 }
 
@@ -28,40 +43,210 @@ func (g *generate) Execute() error {
 	}
 	defer g.adapter.Close()
 
-	var tables []model.Table
-	recipeFilePath := fmt.Sprintf("./%s.recipe.json", g.config.Database)
-	readJson(recipeFilePath, &tables)
+	recipeFilePath := g.config.RecipeFilePath()
+	tables, err := recipe.Read(recipeFilePath)
+	if err != nil {
+		return err
+	}
 
-	// filter out generated columns (these are generated by DB)
+	return g.populate(tables)
+}
+
+// populate generates the rows of the tables into the database of the (initialized) adapter,
+// the summary of the run is written also when it fails
+func (g *generate) populate(tables []model.Table) (err error) {
+	if g.config.SummaryPath != "" {
+		defer func() {
+			status := progress.StatusOK
+			if g.config.DryRun {
+				status = progress.StatusDryRun
+			}
+			summary := g.progress.Summary(status, err)
+			if summaryErr := progress.WriteSummary(g.config.SummaryPath, summary); summaryErr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to write summary: %w", summaryErr))
+			}
+		}()
+	}
+
+	// report all unknown or invalid column settings before any row is written
+	if err := g.gen.Prepare(tables); err != nil {
+		return &recipe.InvalidError{Err: err}
+	}
+
+	for _, table := range tables {
+		if rowCountOf(table, g.config) == 0 {
+			return fmt.Errorf("no row count for table '%s.%s', set rowCount in the recipe or the dataset size", table.Schema, table.Name)
+		}
+	}
+
+	if err := validateTagColumns(tables, g.config); err != nil {
+		return &recipe.InvalidError{Err: err}
+	}
+
+	if err := checkTarget(&g.adapter, tables); err != nil {
+		return err
+	}
+
+	tx, err := newTransaction(&g.adapter, g.config)
+	if err != nil {
+		return err
+	}
+
+	state, err := g.startState(tables)
+	if err != nil {
+		return err
+	}
+
+	// the iterations are known once the run is resumed or appended
+	if err := checkUniqueCapacity(tables, g.config); err != nil {
+		return err
+	}
+
+	if err := tx.beginRun(); err != nil {
+		return err
+	}
+
+	// the data of previous runs is removed in the run transaction (or the one of the first table or batch),
+	// so it is rolled back along with the rows, without transactions it can't be undone.
+	// A resumed run keeps the rows it committed before.
+	if len(state.Tables) == 0 {
+		if err := tx.beginCleanup(); err != nil {
+			return err
+		}
+		if err := cleanTables(&g.adapter, g.gen, g.config, tables); err != nil {
+			return tx.endRun(err)
+		}
+	}
+
+	var errs []error
 	for _, table := range tables {
-		var nonGenColumns []model.Column
-		for _, column := range table.Columns {
-			if !column.IsGenerated {
-				nonGenColumns = append(nonGenColumns, column)
+		err := tx.beginTable()
+		if err == nil {
+			err = g.populateTable(tx, state, table)
+		}
+
+		err = tx.endTable(err)
+		g.progress.FinishTable(err)
+		if err != nil {
+			if !tx.dryRun {
+				return err
 			}
+			// a dry run carries on to report the constraint violations of every table
+			errs = append(errs, err)
+			continue
 		}
 
-		for iter := range g.config.DataSize {
-			if err := g.adapter.WriteRow(table.Name, table.Schema, nonGenColumns, g.config.IterFrom+iter); err != nil {
-				return fmt.Errorf("failed to write row to table: %w", err)
+		if tx.commitsTables() {
+			if err := g.recordProgress(state, table, rowCountOf(table, g.config)); err != nil {
+				return err
 			}
 		}
 	}
 
-	fmt.Println("Databse was populated successfully")
+	if err := tx.endRun(errors.Join(errs...)); err != nil {
+		return err
+	}
+
+	if tx.dryRun {
+		slog.Info("dry run succeeded, all rows were rolled back")
+		return nil
+	}
+
+	// the run is complete, there is nothing left to resume
+	if g.config.StatePath != "" {
+		if err := os.Remove(g.config.StatePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove state: %w", err)
+		}
+	}
+
+	slog.Info("database was populated successfully")
 
 	return nil
 }
 
-func readJson(filePath string, tables *[]model.Table) error {
-	contents, err := os.ReadFile(filePath)
-	if err != nil {
-		return err
+// startState resumes the run recorded in the state file (if there is one), otherwise
+// starts a new one from the given or the detected iteration
+func (g *generate) startState(tables []model.Table) (*runState, error) {
+	if g.config.StatePath != "" {
+		state, err := readState(g.config.StatePath)
+		if err != nil {
+			return nil, err
+		}
+		if state != nil {
+			slog.Info("resuming the run", "iterFrom", state.IterFrom, "state", g.config.StatePath)
+			g.config.IterFrom = state.IterFrom
+			return state, nil
+		}
+	}
+
+	if g.config.AutoIter {
+		iterFrom, err := detectNextIter(&g.adapter, tables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect the next iteration: %w", err)
+		}
+		slog.Info("appending after the existing rows", "iterFrom", iterFrom)
+		g.config.IterFrom = iterFrom
+	}
+
+	return &runState{IterFrom: g.config.IterFrom, Tables: make(map[string]uint32)}, nil
+}
+
+// recordProgress records the committed rows of the table into the state file
+func (g *generate) recordProgress(state *runState, table model.Table, rows uint32) error {
+	if g.config.StatePath == "" {
+		return nil
+	}
+
+	state.Tables[stateKey(table)] = rows
+	return writeState(g.config.StatePath, state)
+}
+
+func (g *generate) populateTable(tx *transaction, state *runState, table model.Table) error {
+	// filter out generated columns (these are generated by DB)
+	var nonGenColumns []model.Column
+	for _, column := range table.Columns {
+		if !column.IsGenerated {
+			nonGenColumns = append(nonGenColumns, column)
+		}
 	}
 
-	err = json.Unmarshal(contents, tables)
+	tagIndex := slices.IndexFunc(nonGenColumns, func(column model.Column) bool { return column.Name == table.TagColumn })
+
+	// rows committed by the resumed run are skipped
+	start := state.committed(table)
+	rowCount := rowCountOf(table, g.config)
+	g.progress.StartTable(table.Schema+"."+table.Name, start, rowCount)
+
+	for iter := start; iter < rowCount; iter++ {
+		if err := tx.batch(iter - start); err != nil {
+			return err
+		}
+		if tx.commitsBatch(iter - start) {
+			if err := g.recordProgress(state, table, iter); err != nil {
+				return err
+			}
+		}
+
+		if err := g.writeRow(table, nonGenColumns, tagIndex, iter); err != nil {
+			return err
+		}
+		g.progress.Add(1)
+	}
+
+	return nil
+}
+
+func (g *generate) writeRow(table model.Table, columns []model.Column, tagIndex int, iter uint32) error {
+	values, err := g.gen.GenVals(table.Schema+"."+table.Name, columns, g.config.IterFrom+iter)
 	if err != nil {
-		return err
+		return &RowError{table.WriteSchema() + "." + table.Name, g.config.IterFrom + iter, err}
+	}
+	if tagIndex >= 0 {
+		values[tagIndex] = tagOf(g.config)
+	}
+
+	if err := g.adapter.WriteRow(table.Name, table.WriteSchema(), columns, values); err != nil {
+		return &RowError{table.WriteSchema() + "." + table.Name, g.config.IterFrom + iter, err}
 	}
 
 	return nil
@@ -3,9 +3,12 @@ package action
 import (
 	"dbaker/pkg/adapter"
 	"dbaker/pkg/config"
+	"dbaker/pkg/generator"
 	"dbaker/pkg/model"
-	"encoding/json"
+	"dbaker/pkg/recipe"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"strings"
 )
@@ -23,52 +26,124 @@ func NewIntrospect(config config.Config, adapter adapter.PostgreSQLAdapter) *int
 }
 
 func (i *introspect) Execute() error {
+	// merging is meant to keep the hand edits, so commented recipes are not rewritten unless forced
+	if i.config.Merge && !i.config.Force {
+		if err := recipe.CheckComments(i.config.RecipeFilePath()); err != nil {
+			return err
+		}
+	}
+
 	err := i.adapter.Init()
 	if err != nil {
 		return err
 	}
 	defer i.adapter.Close()
 
+	tables, err := introspectTables(&i.adapter, i.config.Tables)
+	if err != nil {
+		return err
+	}
+
+	recipeFilePath := i.config.RecipeFilePath()
+	if i.config.Merge {
+		if tables, err = mergeRecipe(recipeFilePath, tables); err != nil {
+			return err
+		}
+	}
+
+	warnInvalidRecipe(i.config, tables)
+
+	if err := recipe.Write(recipeFilePath, tables); err != nil {
+		return err
+	}
+
+	slog.Info("recipe written", "path", recipeFilePath)
+
+	return nil
+}
+
+// introspectTables reads the schema of the given tables (in schema.table format) from the database
+func introspectTables(adapter *adapter.PostgreSQLAdapter, tableNames []string) ([]*model.Table, error) {
 	var tables []*model.Table
-	for _, tbl := range i.config.Tables {
+	for _, tbl := range tableNames {
 		tableName, schema := splitTableName(tbl)
 		if tableName == "" || schema == "" {
-			return fmt.Errorf("provided invalid table name: %s", tbl)
+			return nil, fmt.Errorf("provided invalid table name: %s", tbl)
 		}
 
-		fmt.Printf("Introspecting table: %s ...\n", tbl)
+		slog.Info("introspecting table", "table", tbl)
 
-		table, err := i.adapter.IntrospectTable(tableName, schema)
+		table, err := adapter.IntrospectTable(tableName, schema)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		// inferred annotations end up in the recipe, so they can be reviewed and edited
+		generator.InferAnnotations(table)
+
+		for _, check := range table.UnparsedChecks {
+			slog.Warn("check constraint can't be satisfied by the generator", "table", tbl, "check", check)
 		}
 
 		tables = append(tables, table)
-		fmt.Println("done.")
+		slog.Debug("table introspected", "table", tbl, "columns", len(table.Columns))
 	}
 
-	recipeFilePath := fmt.Sprintf("./%s.recipe.json", i.config.Database)
-	if err := writeJson(recipeFilePath, tables); err != nil {
-		return err
+	return tables, nil
+}
+
+// mergeRecipe merges the introspected tables into the existing recipe file (when there is one)
+// keeping the user authored settings, the schema changes are printed
+func mergeRecipe(recipeFilePath string, tables []*model.Table) ([]*model.Table, error) {
+	if _, err := os.Stat(recipeFilePath); errors.Is(err, os.ErrNotExist) {
+		slog.Info("no recipe to merge into, writing a new one", "path", recipeFilePath)
+		return tables, nil
 	}
 
-	fmt.Printf("Baking recepi written to %s\n", recipeFilePath)
+	existing, err := recipe.Read(recipeFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the recipe to merge into: %w", err)
+	}
 
-	return nil
+	merged, changes := recipe.Merge(existing, tables)
+	if len(changes) == 0 {
+		fmt.Println("No schema changes since the last introspection")
+	} else {
+		fmt.Printf("Schema changes since the last introspection:\n")
+		for _, change := range changes {
+			fmt.Printf("  %s\n", change)
+		}
+	}
+
+	return merged, nil
 }
 
-func writeJson(filePath string, tables []*model.Table) error {
-	json, err := json.MarshalIndent(tables, "", "  ")
-	if err != nil {
-		return err
+// warnInvalidRecipe reports recipe parts which generate would reject (e.g. unsupported column types),
+// the recipe is written anyway so that they can be fixed by hand
+func warnInvalidRecipe(config config.Config, tables []*model.Table) {
+	err := recipe.ValidateTables(tables)
+	if err == nil {
+		baked := make([]model.Table, len(tables))
+		for index, table := range tables {
+			baked[index] = *table
+		}
+		err = generator.NewValueGenerator(config).Prepare(baked)
 	}
 
-	err = os.WriteFile(filePath, json, 0644)
 	if err != nil {
-		return err
+		slog.Warn("the recipe needs to be edited before generating data")
+		for _, problem := range problems(err) {
+			slog.Warn(problem.Error())
+		}
 	}
+}
 
-	return nil
+// problems splits joined errors up to report them one by one
+func problems(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
 }
 
 func splitTableName(table string) (name string, schema string) {
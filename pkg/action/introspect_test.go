@@ -1,9 +1,6 @@
 package action
 
 import (
-	"dbaker/pkg/model"
-	"encoding/json"
-	"os"
 	"testing"
 )
 
@@ -61,98 +58,3 @@ func TestSplitTableName(t *testing.T) {
 		})
 	}
 }
-
-func TestWriteJson(t *testing.T) {
-	testCases := []struct {
-		name    string
-		tables  []*model.Table
-		wantErr bool
-	}{
-		{
-			name:    "empty tables slice",
-			tables:  []*model.Table{},
-			wantErr: false,
-		},
-		{
-			name: "single table",
-			tables: []*model.Table{
-				{
-					Name:   "users",
-					Schema: "public",
-					Columns: []model.Column{
-						{Name: "id", Typ: model.Int, IsGenerated: true},
-						{Name: "name", Typ: model.Varchar, MaxLength: 255, IsNullable: false},
-					},
-				},
-			},
-			wantErr: false,
-		},
-		{
-			name: "multiple tables",
-			tables: []*model.Table{
-				{
-					Name:   "users",
-					Schema: "public",
-					Columns: []model.Column{
-						{Name: "id", Typ: model.Int, IsGenerated: true},
-						{Name: "name", Typ: model.Varchar, MaxLength: 255, IsNullable: false},
-					},
-				},
-				{
-					Name:   "products",
-					Schema: "public",
-					Columns: []model.Column{
-						{Name: "product_id", Typ: model.UUID, IsUnique: true},
-						{Name: "price", Typ: model.Decimal, IsNullable: false},
-					},
-				},
-			},
-			wantErr: false,
-		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			tmpfile, err := os.CreateTemp("", "test_recipe_*.json")
-			if err != nil {
-				t.Fatalf("failed to create temporary file: %v", err)
-			}
-			defer os.Remove(tmpfile.Name())
-			tmpfile.Close() // Close the file handle so writeJson can open it
-
-			err = writeJson(tmpfile.Name(), tc.tables)
-			if (err != nil) != tc.wantErr {
-				t.Errorf("writeJson() error = %v, wantErr %v", err, tc.wantErr)
-				return
-			}
-
-			if !tc.wantErr {
-				contents, err := os.ReadFile(tmpfile.Name())
-				if err != nil {
-					t.Fatalf("failed to read written file: %v", err)
-				}
-
-				var readTables []*model.Table
-				err = json.Unmarshal(contents, &readTables)
-				if err != nil {
-					t.Fatalf("failed to unmarshal JSON from file: %v", err)
-				}
-
-				// Marshal both original and read tables to canonical JSON for comparison
-				// Using json.Marshal (without indent) ensures consistent comparison regardless of formatting
-				originalJSON, err := json.Marshal(tc.tables)
-				if err != nil {
-					t.Fatalf("failed to marshal original tables for comparison: %v", err)
-				}
-				readJSON, err := json.Marshal(readTables)
-				if err != nil {
-					t.Fatalf("failed to marshal read tables for comparison: %v", err)
-				}
-
-				if string(originalJSON) != string(readJSON) {
-					t.Errorf("written JSON does not match original.\nExpected: %s\nGot: %s", string(originalJSON), string(readJSON))
-				}
-			}
-		})
-	}
-}
@@ -0,0 +1,92 @@
+package action
+
+import (
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+	"dbaker/pkg/generator"
+	"dbaker/pkg/mask"
+	"dbaker/pkg/recipe"
+	"fmt"
+	"log/slog"
+)
+
+type maskAction struct {
+	config        config.Config
+	sourceAdapter adapter.PostgreSQLAdapter
+	targetAdapter adapter.PostgreSQLAdapter
+	gen           *generator.ValueGenerator
+	masker        *mask.Masker
+}
+
+func NewMask(config config.Config, sourceAdapter adapter.PostgreSQLAdapter, targetAdapter adapter.PostgreSQLAdapter) *maskAction {
+	gen := generator.NewValueGenerator(config)
+	return &maskAction{
+		config,
+		sourceAdapter,
+		targetAdapter,
+		gen,
+		mask.NewMasker(config, gen),
+	}
+}
+
+// Execute copies rows of the recipe tables from the source into the target database,
+// values are transformed by the column masks on the way. Tables are copied in the recipe order.
+func (m *maskAction) Execute() error {
+	recipeFilePath := m.config.RecipeFilePath()
+	tables, err := recipe.Read(recipeFilePath)
+	if err != nil {
+		return err
+	}
+
+	// report all unknown or invalid column settings before any row is read
+	if err := m.gen.Prepare(tables); err != nil {
+		return &recipe.InvalidError{Err: err}
+	}
+	if err := m.masker.Prepare(tables); err != nil {
+		return &recipe.InvalidError{Err: err}
+	}
+
+	if m.config.MaskKey == "" {
+		slog.Warn("no mask key given, hashed and format masked values differ from run to run")
+	}
+
+	if err := m.sourceAdapter.Init(); err != nil {
+		return err
+	}
+	defer m.sourceAdapter.Close()
+
+	if err := m.targetAdapter.Init(); err != nil {
+		return err
+	}
+	defer m.targetAdapter.Close()
+
+	iter := m.config.IterFrom
+	for _, table := range tables {
+		slog.Info("masking table", "table", table.Schema+"."+table.Name)
+
+		rowCount := 0
+		err := m.sourceAdapter.ReadRows(table.Name, table.Schema, table.Columns, func(values []any) error {
+			masked, err := m.masker.MaskRow(table.Columns, values, iter)
+			if err != nil {
+				return &RowError{table.Schema + "." + table.Name, iter, fmt.Errorf("failed to mask row: %w", err)}
+			}
+
+			if err := m.targetAdapter.WriteRow(table.Name, table.WriteSchema(), table.Columns, masked); err != nil {
+				return &RowError{table.WriteSchema() + "." + table.Name, iter, err}
+			}
+
+			iter++
+			rowCount++
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to mask table '%s.%s': %w", table.Schema, table.Name, err)
+		}
+
+		slog.Info("table masked", "table", table.Schema+"."+table.Name, "rows", rowCount)
+	}
+
+	slog.Info("database was masked successfully")
+
+	return nil
+}
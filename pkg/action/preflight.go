@@ -0,0 +1,183 @@
+package action
+
+import (
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+	"dbaker/pkg/generator"
+	"dbaker/pkg/model"
+	"dbaker/pkg/recipe"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// typeRanks places the column types into families, a column takes the values generated for the types
+// of its family with a lower or the same rank
+var typeRanks = map[model.ColumnType]struct{ family, rank int }{
+	model.SmallInt: {0, 0}, model.Int: {0, 1}, model.BigInt: {0, 2},
+	model.Real: {1, 0}, model.Double: {1, 1},
+	model.Char: {2, 0}, model.Varchar: {2, 0}, model.Text: {2, 1},
+	model.Date: {3, 0}, model.Timestamp: {3, 1}, model.TimestampTZ: {3, 1},
+	model.UUID:    {4, 0},
+	model.Boolean: {5, 0},
+	model.Time:    {6, 0},
+}
+
+// checkTarget verifies that the target database can take the rows of the recipe before anything is written:
+// the tables and columns exist with compatible types, referenced tables are generated before or populated
+// already, and the user may insert rows. All problems are reported at once.
+func checkTarget(targetAdapter *adapter.PostgreSQLAdapter, tables []model.Table) error {
+	var problems []error
+	populated := make(map[string]bool)
+	for index, table := range tables {
+		name := table.WriteSchema() + "." + table.Name
+
+		target, err := targetAdapter.IntrospectTable(table.Name, table.WriteSchema())
+		if errors.Is(err, adapter.ErrTableNotFound) {
+			problems = append(problems, fmt.Errorf("table '%s' does not exist in the target database, migrate the target schema or map the schema (schemaMapping)", name))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		problems = append(problems, checkColumns(name, table, *target)...)
+
+		granted, err := targetAdapter.HasInsertPrivilege(table.Name, table.WriteSchema())
+		if err != nil {
+			return err
+		}
+		if !granted {
+			problems = append(problems, fmt.Errorf("table '%s': the database user may not insert rows, grant insert on it to the user", name))
+		}
+
+		for _, column := range table.Columns {
+			if problem := checkReference(targetAdapter, tables, index, column, populated); problem != nil {
+				problems = append(problems, fmt.Errorf("table '%s', column '%s': %w", name, column.Name, problem))
+			}
+		}
+	}
+
+	if err := errors.Join(problems...); err != nil {
+		return fmt.Errorf("the target database can't take the rows of the recipe, nothing was written:\n%w", err)
+	}
+	return nil
+}
+
+// checkColumns compares the written columns of the recipe table with the columns of the target table
+func checkColumns(name string, table model.Table, target model.Table) []error {
+	var problems []error
+	for _, column := range table.Columns {
+		if column.IsGenerated {
+			continue
+		}
+
+		index := slices.IndexFunc(target.Columns, func(targetColumn model.Column) bool { return targetColumn.Name == column.Name })
+		if index < 0 {
+			problems = append(problems, fmt.Errorf("table '%s', column '%s' does not exist in the target database, remove it from the recipe or migrate the target schema", name, column.Name))
+			continue
+		}
+		targetColumn := target.Columns[index]
+
+		if mismatch := typeMismatch(column, targetColumn); mismatch != "" {
+			problems = append(problems, fmt.Errorf("table '%s', column '%s': %s, align the column type in the recipe (introspect --merge) or in the target schema", name, column.Name, mismatch))
+		}
+		if targetColumn.IsGenerated {
+			problems = append(problems, fmt.Errorf("table '%s', column '%s' is an identity column in the target database, mark it as generated (isGenerated) in the recipe", name, column.Name))
+		}
+		if targetColumn.IsUnique && !column.IsUnique {
+			problems = append(problems, fmt.Errorf("table '%s', column '%s' is unique in the target database, set isUnique in the recipe so that its values don't collide", name, column.Name))
+		}
+	}
+
+	return problems
+}
+
+// typeMismatch describes why the values generated for the recipe column don't fit the target column,
+// empty when they do
+func typeMismatch(column model.Column, target model.Column) string {
+	from, fromKnown := typeRanks[column.Typ]
+	to, toKnown := typeRanks[target.Typ]
+	if !fromKnown || !toKnown || from.family != to.family || from.rank > to.rank {
+		return fmt.Sprintf("recipe type %s does not fit target type %s", typeName(column), typeName(target))
+	}
+
+	// text of any length is generated for unbounded columns
+	if target.MaxLength > 0 && (column.MaxLength == 0 || column.MaxLength > target.MaxLength) {
+		return fmt.Sprintf("recipe type %s does not fit target type %s", typeName(column), typeName(target))
+	}
+
+	return ""
+}
+
+// checkReference verifies that the table referenced by the foreign key column is generated before the table
+// at the index, or else is populated in the target already
+func checkReference(targetAdapter *adapter.PostgreSQLAdapter, tables []model.Table, index int, column model.Column, populated map[string]bool) error {
+	schema, name, _, ok := column.ForeignKeyRef()
+	if !ok || column.IsGenerated {
+		return nil
+	}
+
+	table := tables[index]
+	parent := slices.IndexFunc(tables, func(other model.Table) bool { return other.Schema == schema && other.Name == name })
+	switch {
+	case parent == index:
+		return nil
+	case parent > index:
+		return fmt.Errorf("references table '%s.%s' which is generated later, move it before table '%s.%s' in the recipe", schema, name, table.Schema, table.Name)
+	case parent >= 0:
+		return nil
+	}
+
+	// the referenced table is written into the schema the recipe maps its schema to
+	writeSchema := schema
+	if mapped := slices.IndexFunc(tables, func(other model.Table) bool { return other.Schema == schema }); mapped >= 0 {
+		writeSchema = tables[mapped].WriteSchema()
+	}
+
+	key := writeSchema + "." + name
+	hasRows, checked := populated[key]
+	if !checked {
+		var err error
+		if hasRows, err = targetAdapter.HasRows(name, writeSchema); err != nil {
+			return err
+		}
+		populated[key] = hasRows
+	}
+
+	if !hasRows {
+		return fmt.Errorf("references table '%s' which is neither in the recipe nor populated, add it to the recipe before table '%s.%s' or populate it first", key, table.Schema, table.Name)
+	}
+	return nil
+}
+
+// checkUniqueCapacity verifies that the unique columns hold distinct values for all generated iterations
+func checkUniqueCapacity(tables []model.Table, config config.Config) error {
+	var problems []error
+	for _, table := range tables {
+		iterations := uint64(config.IterFrom) + uint64(rowCountOf(table, config))
+		for _, column := range table.Columns {
+			if !column.IsUnique || column.IsGenerated {
+				continue
+			}
+
+			capacity, bounded := generator.UniqueCapacity(column)
+			if bounded && iterations > capacity {
+				problems = append(problems, fmt.Errorf("table '%s.%s', column '%s': unique %s holds %d distinct values, but %d iterations are generated (up to iterFrom + row count), lower the row count or widen the column",
+					table.Schema, table.Name, column.Name, typeName(column), capacity, iterations))
+			}
+		}
+	}
+
+	if err := errors.Join(problems...); err != nil {
+		return &recipe.InvalidError{Err: err}
+	}
+	return nil
+}
+
+func typeName(column model.Column) string {
+	if column.MaxLength > 0 && (column.Typ == model.Char || column.Typ == model.Varchar) {
+		return fmt.Sprintf("%s(%d)", column.Typ, column.MaxLength)
+	}
+	return string(column.Typ)
+}
@@ -0,0 +1,95 @@
+package action
+
+import (
+	"dbaker/pkg/config"
+	"dbaker/pkg/model"
+	"strings"
+	"testing"
+)
+
+func TestTypeMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   model.Column
+		target   model.Column
+		mismatch bool
+	}{
+		{name: "same type", column: model.Column{Typ: model.Int}, target: model.Column{Typ: model.Int}},
+		{name: "wider integer", column: model.Column{Typ: model.SmallInt}, target: model.Column{Typ: model.BigInt}},
+		{name: "narrower integer", column: model.Column{Typ: model.BigInt}, target: model.Column{Typ: model.SmallInt}, mismatch: true},
+		{name: "other family", column: model.Column{Typ: model.Int}, target: model.Column{Typ: model.Text}, mismatch: true},
+		{name: "timestamp with time zone", column: model.Column{Typ: model.TimestampTZ}, target: model.Column{Typ: model.Timestamp}},
+		{name: "varchar into text", column: model.Column{Typ: model.Varchar, MaxLength: 20}, target: model.Column{Typ: model.Text}},
+		{name: "longer varchar", column: model.Column{Typ: model.Varchar, MaxLength: 10}, target: model.Column{Typ: model.Varchar, MaxLength: 20}},
+		{name: "shorter varchar", column: model.Column{Typ: model.Varchar, MaxLength: 20}, target: model.Column{Typ: model.Varchar, MaxLength: 10}, mismatch: true},
+		{name: "text into varchar", column: model.Column{Typ: model.Text}, target: model.Column{Typ: model.Varchar, MaxLength: 10}, mismatch: true},
+		{name: "unsupported target type", column: model.Column{Typ: model.Text}, target: model.Column{Typ: "jsonb"}, mismatch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := typeMismatch(tt.column, tt.target); (got != "") != tt.mismatch {
+				t.Errorf("typeMismatch() = %q; want mismatch %v", got, tt.mismatch)
+			}
+		})
+	}
+}
+
+func TestCheckColumns(t *testing.T) {
+	table := model.Table{Name: "users", Schema: "public", Columns: []model.Column{
+		{Name: "id", Typ: model.Int, IsGenerated: true},
+		{Name: "email", Typ: model.Varchar, MaxLength: 50},
+		{Name: "nickname", Typ: model.Text},
+		{Name: "code", Typ: model.Int},
+		{Name: "age", Typ: model.SmallInt},
+	}}
+	target := model.Table{Name: "users", Schema: "public", Columns: []model.Column{
+		{Name: "id", Typ: model.Int, IsGenerated: true},
+		{Name: "email", Typ: model.Varchar, MaxLength: 50, IsUnique: true},
+		{Name: "code", Typ: model.Int, IsGenerated: true},
+		{Name: "age", Typ: model.Int},
+	}}
+
+	problems := checkColumns("public.users", table, target)
+
+	want := []string{
+		"column 'email' is unique in the target database",
+		"column 'nickname' does not exist in the target database",
+		"column 'code' is an identity column in the target database",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("checkColumns() = %v; want %d problems", problems, len(want))
+	}
+	for index, problem := range problems {
+		if !strings.Contains(problem.Error(), want[index]) {
+			t.Errorf("checkColumns()[%d] = %v; want containing %q", index, problem, want[index])
+		}
+	}
+}
+
+func TestCheckUniqueCapacity(t *testing.T) {
+	tests := []struct {
+		name    string
+		column  model.Column
+		config  config.Config
+		wantErr bool
+	}{
+		{name: "int", column: model.Column{Name: "id", Typ: model.Int, IsUnique: true}, config: config.Config{DataSize: 100000}},
+		{name: "smallint", column: model.Column{Name: "id", Typ: model.SmallInt, IsUnique: true}, config: config.Config{DataSize: 100000}, wantErr: true},
+		{name: "smallint from iteration", column: model.Column{Name: "id", Typ: model.SmallInt, IsUnique: true}, config: config.Config{DataSize: 100, IterFrom: 32700}, wantErr: true},
+		{name: "varchar(2)", column: model.Column{Name: "code", Typ: model.Varchar, MaxLength: 2, IsUnique: true}, config: config.Config{DataSize: 100}},
+		{name: "varchar(2) too short", column: model.Column{Name: "code", Typ: model.Varchar, MaxLength: 2, IsUnique: true}, config: config.Config{DataSize: 101}, wantErr: true},
+		{name: "not unique", column: model.Column{Name: "flag", Typ: model.Boolean}, config: config.Config{DataSize: 100}},
+		{name: "unique bool", column: model.Column{Name: "flag", Typ: model.Boolean, IsUnique: true}, config: config.Config{DataSize: 3}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables := []model.Table{{Name: "t", Schema: "public", Columns: []model.Column{tt.column}}}
+			err := checkUniqueCapacity(tables, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkUniqueCapacity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package action
+
+import (
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+	"dbaker/pkg/generator"
+	"dbaker/pkg/recipe"
+	"fmt"
+	"log/slog"
+)
+
+type profile struct {
+	config  config.Config
+	adapter adapter.PostgreSQLAdapter
+}
+
+func NewProfile(config config.Config, adapter adapter.PostgreSQLAdapter) *profile {
+	return &profile{
+		config,
+		adapter,
+	}
+}
+
+// Execute introspects the tables like introspect does and additionally profiles their data,
+// the recipe then carries row counts, null ratios and distributions of the live database
+func (p *profile) Execute() error {
+	err := p.adapter.Init()
+	if err != nil {
+		return err
+	}
+	defer p.adapter.Close()
+
+	tables, err := introspectTables(&p.adapter, p.config.Tables)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		slog.Info("profiling table", "table", table.Schema+"."+table.Name)
+
+		if err := p.adapter.ProfileTable(table); err != nil {
+			return fmt.Errorf("failed to profile table '%s.%s': %w", table.Schema, table.Name, err)
+		}
+
+		// the recipe must not carry values of the data unless asked to
+		if !p.config.ExactStats {
+			generator.CoarsenStats(table)
+		}
+		generator.ApplyStats(table)
+		slog.Info("table profiled", "table", table.Schema+"."+table.Name, "rows", table.RowCount)
+	}
+
+	warnInvalidRecipe(p.config, tables)
+
+	recipeFilePath := p.config.RecipeFilePath()
+	if err := recipe.Write(recipeFilePath, tables); err != nil {
+		return err
+	}
+
+	slog.Info("recipe written", "path", recipeFilePath)
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package action
+
+import (
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+	"dbaker/pkg/generator"
+	"dbaker/pkg/mask"
+	"dbaker/pkg/model"
+	"dbaker/pkg/recipe"
+	"errors"
+	"fmt"
+)
+
+type recipeValidate struct {
+	config    config.Config
+	filePaths []string
+}
+
+func NewRecipeValidate(config config.Config, filePaths []string) *recipeValidate {
+	return &recipeValidate{
+		config,
+		filePaths,
+	}
+}
+
+// Execute validates the recipe files against the schema and checks the column settings
+// (annotations, distributions, null ratios, masks) the same way generate and mask do
+func (r *recipeValidate) Execute() error {
+	invalid := 0
+	for _, filePath := range r.filePaths {
+		if err := r.validate(filePath); err != nil {
+			fmt.Printf("%s: invalid\n%s\n", filePath, err)
+			invalid++
+			continue
+		}
+
+		fmt.Printf("%s: ok\n", filePath)
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d recipes are invalid", invalid, len(r.filePaths))
+	}
+
+	return nil
+}
+
+func (r *recipeValidate) validate(filePath string) error {
+	tables, err := recipe.Read(filePath)
+	if err != nil {
+		return err
+	}
+
+	gen := generator.NewValueGenerator(r.config)
+	return errors.Join(
+		gen.Prepare(tables),
+		mask.NewMasker(r.config, gen).Prepare(tables),
+	)
+}
+
+type recipeUpgrade struct {
+	config    config.Config
+	filePaths []string
+}
+
+func NewRecipeUpgrade(config config.Config, filePaths []string) *recipeUpgrade {
+	return &recipeUpgrade{
+		config,
+		filePaths,
+	}
+}
+
+// Execute rewrites the recipe files in place in the current recipe version
+func (r *recipeUpgrade) Execute() error {
+	var errs []error
+	for _, filePath := range r.filePaths {
+		version, err := recipe.Upgrade(filePath, r.config.Force)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if version == recipe.CurrentVersion {
+			fmt.Printf("%s: already at version %d\n", filePath, version)
+		} else {
+			fmt.Printf("%s: upgraded from version %d to %d\n", filePath, version, recipe.CurrentVersion)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+type recipeDiff struct {
+	config         config.Config
+	adapter        adapter.PostgreSQLAdapter
+	recipeFilePath string
+}
+
+func NewRecipeDiff(config config.Config, adapter adapter.PostgreSQLAdapter, recipeFilePath string) *recipeDiff {
+	return &recipeDiff{
+		config,
+		adapter,
+		recipeFilePath,
+	}
+}
+
+// Execute compares the recipe tables with the live database schema,
+// any difference is printed and makes the command fail
+func (r *recipeDiff) Execute() error {
+	tables, err := recipe.Read(r.recipeFilePath)
+	if err != nil {
+		return err
+	}
+
+	err = r.adapter.Init()
+	if err != nil {
+		return err
+	}
+	defer r.adapter.Close()
+
+	var live []*model.Table
+	for _, table := range tables {
+		liveTable, err := r.adapter.IntrospectTable(table.Name, table.Schema)
+		if errors.Is(err, adapter.ErrTableNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to introspect table '%s.%s': %w", table.Schema, table.Name, err)
+		}
+
+		live = append(live, liveTable)
+	}
+
+	changes := recipe.Diff(tables, live)
+	if len(changes) == 0 {
+		fmt.Printf("%s matches the database schema\n", r.recipeFilePath)
+		return nil
+	}
+
+	fmt.Printf("%s differs from the database schema:\n", r.recipeFilePath)
+	for _, change := range changes {
+		fmt.Printf("  %s\n", change)
+	}
+
+	return fmt.Errorf("recipe %s is out of date, %d schema changes", r.recipeFilePath, len(changes))
+}
+
+type recipeConvert struct {
+	config       config.Config
+	fromFilePath string
+	toFilePath   string
+}
+
+func NewRecipeConvert(config config.Config, fromFilePath string, toFilePath string) *recipeConvert {
+	return &recipeConvert{
+		config,
+		fromFilePath,
+		toFilePath,
+	}
+}
+
+// Execute converts the recipe file into the format given by the extension of the target file
+func (r *recipeConvert) Execute() error {
+	if err := recipe.Convert(r.fromFilePath, r.toFilePath); err != nil {
+		return err
+	}
+
+	fmt.Printf("Recipe %s converted into %s\n", r.fromFilePath, r.toFilePath)
+
+	return nil
+}
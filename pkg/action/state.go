@@ -0,0 +1,137 @@
+package action
+
+import (
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/generator"
+	"dbaker/pkg/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// runState is the progress of a generate run, recorded whenever rows are committed,
+// so that an interrupted run can be resumed from its last committed batch
+type runState struct {
+	IterFrom uint32 `json:"iterFrom"`
+	// number of rows committed per table (schema.table)
+	Tables map[string]uint32 `json:"tables"`
+}
+
+// committed returns the rows of the table committed by the resumed run, these are skipped
+func (s *runState) committed(table model.Table) uint32 {
+	return s.Tables[stateKey(table)]
+}
+
+func stateKey(table model.Table) string {
+	return table.Schema + "." + table.Name
+}
+
+// readState reads the state file, nil when there is none
+func readState(filePath string) (*runState, error) {
+	contents, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	var state runState
+	if err := json.Unmarshal(contents, &state); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", filePath, err)
+	}
+	if state.Tables == nil {
+		state.Tables = make(map[string]uint32)
+	}
+
+	return &state, nil
+}
+
+// writeState replaces the state file at once, a failure midway never leaves a truncated state behind
+func writeState(filePath string, state *runState) error {
+	contents, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(contents); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	if err := os.Rename(temp.Name(), filePath); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
+
+// detectNextIter returns the iteration following the highest unique values of the tables generated by dbaker,
+// appending rows from there on does not collide with the existing ones. Tables with unique columns none of
+// which tells the iteration (e.g. phone numbers) can't be appended to.
+func detectNextIter(adapter *adapter.PostgreSQLAdapter, tables []model.Table) (uint32, error) {
+	var next uint32
+	var errs []error
+	for _, table := range tables {
+		if slices.ContainsFunc(table.Columns, isUniqueValue) && !slices.ContainsFunc(table.Columns, isIterationSource) {
+			errs = append(errs, fmt.Errorf("table '%s.%s' has no unique column telling the iteration of its rows, set --iterFrom instead", table.Schema, table.Name))
+			continue
+		}
+
+		for _, column := range table.Columns {
+			if !isIterationSource(column) {
+				continue
+			}
+
+			var value any
+			var err error
+			if generator.IsTimeOrderedUUID(column) {
+				value, err = adapter.MaxUUIDTime(table, column)
+			} else {
+				value, err = adapter.MaxValue(table, column, generator.UniqueIterPattern(column))
+			}
+			if err != nil {
+				return 0, err
+			}
+
+			iter, err := generator.NextUniqueIter(column, value)
+			if err != nil {
+				return 0, fmt.Errorf("table '%s.%s': %w", table.Schema, table.Name, err)
+			}
+			next = max(next, iter)
+		}
+	}
+
+	return next, errors.Join(errs...)
+}
+
+// isUniqueValue reports whether dbaker generates unique values for the column
+func isUniqueValue(column model.Column) bool {
+	return column.IsUnique && !column.IsGenerated
+}
+
+// isIterationSource reports whether the unique values of the column tell the iteration they were generated on:
+// numbers, dates and timestamps, text holding the iteration and uuid v7 (by its timestamp)
+func isIterationSource(column model.Column) bool {
+	if !isUniqueValue(column) {
+		return false
+	}
+	if generator.IsTimeOrderedUUID(column) || generator.UniqueIterPattern(column) != "" {
+		return true
+	}
+
+	return slices.Contains([]model.ColumnType{
+		model.SmallInt, model.Int, model.BigInt, model.Real, model.Double, model.Date, model.Timestamp, model.TimestampTZ,
+	}, column.Typ)
+}
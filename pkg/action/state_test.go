@@ -0,0 +1,109 @@
+package action
+
+import (
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+	"dbaker/pkg/model"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "seed.state.json")
+
+	state, err := readState(filePath)
+	if err != nil || state != nil {
+		t.Fatalf("readState() = %v, %v; want no state", state, err)
+	}
+
+	written := &runState{IterFrom: 1000, Tables: map[string]uint32{"public.users": 5000, "public.orders": 2000}}
+	if err := writeState(filePath, written); err != nil {
+		t.Fatalf("writeState() unexpected error: %v", err)
+	}
+
+	state, err = readState(filePath)
+	if err != nil {
+		t.Fatalf("readState() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(state, written) {
+		t.Errorf("readState() = %+v; want %+v", state, written)
+	}
+
+	// only the state file itself is left behind
+	entries, err := os.ReadDir(filepath.Dir(filePath))
+	if err != nil {
+		t.Fatalf("failed to read directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("state directory holds %d files; want 1", len(entries))
+	}
+}
+
+func TestStartStateResumesRun(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "seed.state.json")
+	users := model.Table{Name: "users", Schema: "public"}
+	orders := model.Table{Name: "orders", Schema: "public"}
+	cfg := config.Config{StatePath: filePath, IterFrom: 7, Quiet: true}
+
+	// without a state file the run starts from the given iteration with nothing committed
+	gen := NewGenerate(cfg, adapter.NewPostgreSQLAdapter(cfg))
+	state, err := gen.startState(nil)
+	if err != nil {
+		t.Fatalf("startState() unexpected error: %v", err)
+	}
+	if state.IterFrom != 7 || state.committed(users) != 0 {
+		t.Errorf("startState() = %+v; want a new run from iteration 7", state)
+	}
+
+	// the committed rows are recorded and skipped by the resumed run, along with its iteration
+	if err := gen.recordProgress(state, users, 2000); err != nil {
+		t.Fatalf("recordProgress() unexpected error: %v", err)
+	}
+
+	cfg.IterFrom = 0
+	resumed := NewGenerate(cfg, adapter.NewPostgreSQLAdapter(cfg))
+	state, err = resumed.startState(nil)
+	if err != nil {
+		t.Fatalf("startState() unexpected error: %v", err)
+	}
+	if resumed.config.IterFrom != 7 || state.committed(users) != 2000 || state.committed(orders) != 0 {
+		t.Errorf("startState() = %+v, iterFrom %d; want 2000 users committed from iteration 7", state, resumed.config.IterFrom)
+	}
+}
+
+func TestReadStateRejectsInvalidState(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "seed.state.json")
+	if err := os.WriteFile(filePath, []byte(`{"iterFrom": -1}`), 0644); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+
+	if _, err := readState(filePath); err == nil {
+		t.Errorf("readState() expected an error")
+	}
+}
+
+func TestIsIterationSource(t *testing.T) {
+	testCases := []struct {
+		column model.Column
+		want   bool
+	}{
+		{column: model.Column{Name: "id", Typ: model.Int, IsUnique: true}, want: true},
+		{column: model.Column{Name: "code", Typ: model.Varchar, IsUnique: true, IsNullable: true}, want: true},
+		{column: model.Column{Name: "identity", Typ: model.Int, IsUnique: true, IsGenerated: true}, want: false},
+		{column: model.Column{Name: "uuid", Typ: model.UUID, IsUnique: true}, want: true},
+		{column: model.Column{Name: "ref", Typ: model.UUID, IsUnique: true, Annotation: "uuidv5"}, want: false},
+		{column: model.Column{Name: "email", Typ: model.Varchar, IsUnique: true, Annotation: "email"}, want: true},
+		{column: model.Column{Name: "phone", Typ: model.Varchar, IsUnique: true, Annotation: "phone"}, want: false},
+		{column: model.Column{Name: "count", Typ: model.Int}, want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.column.Name, func(t *testing.T) {
+			if got := isIterationSource(tc.column); got != tc.want {
+				t.Errorf("isIterationSource() = %t; want %t", got, tc.want)
+			}
+		})
+	}
+}
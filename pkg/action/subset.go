@@ -0,0 +1,392 @@
+package action
+
+import (
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+	"dbaker/pkg/generator"
+	"dbaker/pkg/mask"
+	"dbaker/pkg/model"
+	"dbaker/pkg/recipe"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+type subset struct {
+	config        config.Config
+	sourceAdapter adapter.PostgreSQLAdapter
+	targetAdapter adapter.PostgreSQLAdapter
+	gen           *generator.ValueGenerator
+	masker        *mask.Masker
+}
+
+func NewSubset(config config.Config, sourceAdapter adapter.PostgreSQLAdapter, targetAdapter adapter.PostgreSQLAdapter) *subset {
+	gen := generator.NewValueGenerator(config)
+	return &subset{
+		config,
+		sourceAdapter,
+		targetAdapter,
+		gen,
+		mask.NewMasker(config, gen),
+	}
+}
+
+// foreignKeyEdge is a foreign key column of the child table referencing a column of the parent table
+type foreignKeyEdge struct {
+	child     int
+	parent    int
+	column    string
+	refColumn string
+}
+
+// selection holds the rows picked from a single table, rows reached from a child row only (the parent
+// of a picked row) are not followed down to their other children, otherwise the subset would grow
+// into the whole database
+type selection struct {
+	rows     []adapter.SelectedRow
+	indexes  map[string]int
+	followed map[string]bool
+}
+
+type pending struct {
+	table  int
+	rows   []adapter.SelectedRow
+	follow bool
+}
+
+// Execute copies a referentially complete slice of the recipe tables from the source into the target
+// database. Rows of the root table are picked first (a sample or a key query), then foreign keys are
+// followed up to the referenced parents and down to the referencing children until nothing new is found.
+// Selected rows are held in memory.
+func (s *subset) Execute() error {
+	recipeFilePath := s.config.RecipeFilePath()
+	tables, err := recipe.Read(recipeFilePath)
+	if err != nil {
+		return err
+	}
+
+	root := findTable(tables, s.config.SubsetRoot)
+	if root < 0 {
+		return fmt.Errorf("root table '%s' is not in the recipe", s.config.SubsetRoot)
+	}
+	if (s.config.SubsetPercent > 0) == (s.config.SubsetQuery != "") {
+		return fmt.Errorf("select the root rows either by a percentage or by a query")
+	}
+	if s.config.SubsetPercent > 100 {
+		return fmt.Errorf("percentage %v is not within <0, 100>", s.config.SubsetPercent)
+	}
+
+	if s.config.Mask {
+		if err := s.gen.Prepare(tables); err != nil {
+			return &recipe.InvalidError{Err: err}
+		}
+		if err := s.masker.Prepare(tables); err != nil {
+			return &recipe.InvalidError{Err: err}
+		}
+		if s.config.MaskKey == "" {
+			slog.Warn("no mask key given, hashed and format masked values differ from run to run")
+		}
+	}
+
+	edges, unresolved := foreignKeyEdges(tables)
+	for _, foreignKey := range unresolved {
+		slog.Warn("foreign key references a table outside of the recipe", "foreignKey", foreignKey)
+	}
+	order, err := parentFirstOrder(tables, edges)
+	if err != nil {
+		return err
+	}
+
+	if err := s.sourceAdapter.Init(); err != nil {
+		return err
+	}
+	defer s.sourceAdapter.Close()
+
+	selections, err := s.selectRows(tables, root, edges)
+	if err != nil {
+		return err
+	}
+
+	if err := s.targetAdapter.Init(); err != nil {
+		return err
+	}
+	defer s.targetAdapter.Close()
+
+	iter := s.config.IterFrom
+	for _, index := range order {
+		table := tables[index]
+		slog.Info("copying table", "table", table.Schema+"."+table.Name)
+
+		rows, err := parentFirstRows(selections[index].rows, selfEdges(index, edges))
+		if err != nil {
+			return fmt.Errorf("table '%s.%s': %w", table.Schema, table.Name, err)
+		}
+
+		for _, row := range rows {
+			values := row.Values
+			if s.config.Mask {
+				if values, err = s.masker.MaskRow(table.Columns, values, iter); err != nil {
+					return fmt.Errorf("failed to mask row on iteration '%d': %w", iter, err)
+				}
+			}
+
+			if err := s.targetAdapter.WriteRow(table.Name, table.WriteSchema(), table.Columns, values); err != nil {
+				return &RowError{table.WriteSchema() + "." + table.Name, iter, err}
+			}
+			iter++
+		}
+
+		slog.Info("table copied", "table", table.Schema+"."+table.Name, "rows", len(selections[index].rows))
+	}
+
+	slog.Info("database subset was copied successfully")
+
+	return nil
+}
+
+func (s *subset) selectRows(tables []model.Table, root int, edges []foreignKeyEdge) ([]selection, error) {
+	selections := make([]selection, len(tables))
+	for index := range selections {
+		selections[index] = selection{indexes: make(map[string]int), followed: make(map[string]bool)}
+	}
+
+	var rootRows []adapter.SelectedRow
+	var err error
+	if s.config.SubsetPercent > 0 {
+		rootRows, err = s.sourceAdapter.SampleRows(tables[root], s.config.SubsetPercent, keyColumns(root, edges))
+	} else {
+		var column string
+		var keys []string
+		if column, keys, err = s.sourceAdapter.QueryKeys(s.config.SubsetQuery); err != nil {
+			return nil, fmt.Errorf("failed to query root keys: %w", err)
+		}
+		rootRows, err = s.sourceAdapter.SelectRowsByKey(tables[root], column, keys, keyColumns(root, edges))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to select root rows: %w", err)
+	}
+
+	queue := []pending{{table: root, rows: rootRows, follow: true}}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		rows := selections[next.table].add(next.rows, next.follow)
+		if len(rows) == 0 {
+			continue
+		}
+
+		for _, edge := range edges {
+			// parents are always needed, children only for rows picked top down
+			if edge.child == next.table {
+				parents, err := s.sourceAdapter.SelectRowsByKey(tables[edge.parent], edge.refColumn, keyValues(rows, edge.column), keyColumns(edge.parent, edges))
+				if err != nil {
+					return nil, fmt.Errorf("failed to select parent rows: %w", err)
+				}
+				queue = append(queue, pending{table: edge.parent, rows: parents})
+			}
+
+			if edge.parent == next.table && next.follow {
+				children, err := s.sourceAdapter.SelectRowsByKey(tables[edge.child], edge.column, keyValues(rows, edge.refColumn), keyColumns(edge.child, edges))
+				if err != nil {
+					return nil, fmt.Errorf("failed to select child rows: %w", err)
+				}
+				queue = append(queue, pending{table: edge.child, rows: children, follow: true})
+			}
+		}
+	}
+
+	return selections, nil
+}
+
+// add adds the rows not selected yet, rows already selected but not followed yet are returned again when
+// they are to be followed now
+func (s *selection) add(rows []adapter.SelectedRow, follow bool) []adapter.SelectedRow {
+	var added []adapter.SelectedRow
+	for _, row := range rows {
+		if _, ok := s.indexes[row.ID]; !ok {
+			s.indexes[row.ID] = len(s.rows)
+			s.rows = append(s.rows, row)
+		} else if !follow || s.followed[row.ID] {
+			continue
+		}
+
+		s.followed[row.ID] = follow
+		added = append(added, row)
+	}
+
+	return added
+}
+
+// foreignKeyEdges resolves the foreign keys between the recipe tables,
+// foreign keys referencing tables outside the recipe are returned separately
+func foreignKeyEdges(tables []model.Table) ([]foreignKeyEdge, []string) {
+	var edges []foreignKeyEdge
+	var unresolved []string
+	for child, table := range tables {
+		for _, column := range table.Columns {
+			if column.ForeignKey == "" {
+				continue
+			}
+
+			schema, name, refColumn, ok := column.ForeignKeyRef()
+			parent := findTable(tables, schema+"."+name)
+			if !ok || parent < 0 {
+				unresolved = append(unresolved, fmt.Sprintf("%s.%s.%s -> %s", table.Schema, table.Name, column.Name, column.ForeignKey))
+				continue
+			}
+
+			edges = append(edges, foreignKeyEdge{child: child, parent: parent, column: column.Name, refColumn: refColumn})
+		}
+	}
+
+	return edges, unresolved
+}
+
+// parentFirstOrder orders the tables so that referenced tables go before the referencing ones,
+// rows are written one by one, so foreign keys forming a cycle between tables are an error
+func parentFirstOrder(tables []model.Table, edges []foreignKeyEdge) ([]int, error) {
+	var order []int
+	done := make([]bool, len(tables))
+	for len(order) < len(tables) {
+		progress := false
+		for index := range tables {
+			if done[index] {
+				continue
+			}
+
+			ready := !slices.ContainsFunc(edges, func(edge foreignKeyEdge) bool {
+				return edge.child == index && edge.parent != index && !done[edge.parent]
+			})
+			if ready {
+				order = append(order, index)
+				done[index] = true
+				progress = true
+			}
+		}
+
+		if !progress {
+			var names []string
+			for index, table := range tables {
+				if !done[index] {
+					names = append(names, table.Schema+"."+table.Name)
+				}
+			}
+			return nil, fmt.Errorf("foreign keys of tables %s form a cycle (or depend on one), their rows can't be written parents first", strings.Join(names, ", "))
+		}
+	}
+
+	return order, nil
+}
+
+// selfEdges returns the foreign keys of the table referencing the table itself
+func selfEdges(table int, edges []foreignKeyEdge) []foreignKeyEdge {
+	var self []foreignKeyEdge
+	for _, edge := range edges {
+		if edge.child == table && edge.parent == table {
+			self = append(self, edge)
+		}
+	}
+
+	return self
+}
+
+// parentFirstRows orders the rows of a self referencing table (e.g. employees by their manager) so that
+// referenced rows go before the referencing ones, rows referencing each other in a cycle are an error
+func parentFirstRows(rows []adapter.SelectedRow, edges []foreignKeyEdge) ([]adapter.SelectedRow, error) {
+	if len(edges) == 0 {
+		return rows, nil
+	}
+
+	// rows by the values of the referenced columns
+	referenced := make(map[string]map[string]int)
+	for _, edge := range edges {
+		if _, ok := referenced[edge.refColumn]; ok {
+			continue
+		}
+		referenced[edge.refColumn] = make(map[string]int)
+		for index, row := range rows {
+			if value, ok := row.Keys[edge.refColumn]; ok {
+				referenced[edge.refColumn][value] = index
+			}
+		}
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	ordered := make([]adapter.SelectedRow, 0, len(rows))
+	states := make([]int, len(rows))
+	var visit func(index int) error
+	visit = func(index int) error {
+		switch states[index] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("rows reference each other in a cycle, e.g. %s", rows[index].ID)
+		}
+
+		states[index] = visiting
+		for _, edge := range edges {
+			value, ok := rows[index].Keys[edge.column]
+			if !ok {
+				continue
+			}
+			// a row referencing itself is fine, the foreign key is checked once the row is in
+			if parent, ok := referenced[edge.refColumn][value]; ok && parent != index {
+				if err := visit(parent); err != nil {
+					return err
+				}
+			}
+		}
+		states[index] = visited
+		ordered = append(ordered, rows[index])
+		return nil
+	}
+
+	for index := range rows {
+		if err := visit(index); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// keyColumns returns the columns of the table which take part in foreign keys
+func keyColumns(table int, edges []foreignKeyEdge) []string {
+	var columns []string
+	for _, edge := range edges {
+		if edge.child == table && !slices.Contains(columns, edge.column) {
+			columns = append(columns, edge.column)
+		}
+		if edge.parent == table && !slices.Contains(columns, edge.refColumn) {
+			columns = append(columns, edge.refColumn)
+		}
+	}
+
+	return columns
+}
+
+func keyValues(rows []adapter.SelectedRow, column string) []string {
+	var values []string
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		if value, ok := row.Keys[column]; ok && !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// findTable finds the table given in schema.table format
+func findTable(tables []model.Table, tableName string) int {
+	name, schema := splitTableName(tableName)
+	return slices.IndexFunc(tables, func(table model.Table) bool {
+		return table.Name == name && table.Schema == schema
+	})
+}
@@ -0,0 +1,131 @@
+package action
+
+import (
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/model"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func subsetTables() []model.Table {
+	return []model.Table{
+		{Name: "order_items", Schema: "shop", Columns: []model.Column{
+			{Name: "order_id", Typ: model.Int, ForeignKey: "shop.orders.id"},
+			{Name: "product_id", Typ: model.Int, ForeignKey: "shop.products.id"},
+		}},
+		{Name: "orders", Schema: "shop", Columns: []model.Column{
+			{Name: "id", Typ: model.Int, IsUnique: true},
+			{Name: "customer_id", Typ: model.Int, ForeignKey: "shop.customers.id"},
+			{Name: "coupon_id", Typ: model.Int, ForeignKey: "billing.coupons.id"},
+		}},
+		{Name: "customers", Schema: "shop", Columns: []model.Column{
+			{Name: "id", Typ: model.Int, IsUnique: true},
+			{Name: "referrer_id", Typ: model.Int, ForeignKey: "shop.customers.id"},
+		}},
+		{Name: "products", Schema: "shop", Columns: []model.Column{
+			{Name: "id", Typ: model.Int, IsUnique: true},
+		}},
+	}
+}
+
+func TestForeignKeyEdges(t *testing.T) {
+	edges, unresolved := foreignKeyEdges(subsetTables())
+
+	expected := []foreignKeyEdge{
+		{child: 0, parent: 1, column: "order_id", refColumn: "id"},
+		{child: 0, parent: 3, column: "product_id", refColumn: "id"},
+		{child: 1, parent: 2, column: "customer_id", refColumn: "id"},
+		{child: 2, parent: 2, column: "referrer_id", refColumn: "id"},
+	}
+	if !reflect.DeepEqual(edges, expected) {
+		t.Errorf("foreignKeyEdges() = %v; want %v", edges, expected)
+	}
+
+	if len(unresolved) != 1 {
+		t.Errorf("foreignKeyEdges() unresolved = %v; want the reference to billing.coupons", unresolved)
+	}
+}
+
+func TestParentFirstOrder(t *testing.T) {
+	tables := subsetTables()
+	edges, _ := foreignKeyEdges(tables)
+
+	order, err := parentFirstOrder(tables, edges)
+	if err != nil {
+		t.Fatalf("parentFirstOrder() unexpected error: %v", err)
+	}
+
+	expected := []int{2, 3, 1, 0}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("parentFirstOrder() = %v; want %v", order, expected)
+	}
+}
+
+func TestParentFirstOrderRejectsCycles(t *testing.T) {
+	tables := []model.Table{
+		{Name: "a", Schema: "public", Columns: []model.Column{{Name: "b_id", ForeignKey: "public.b.id"}}},
+		{Name: "b", Schema: "public", Columns: []model.Column{{Name: "a_id", ForeignKey: "public.a.id"}}},
+		{Name: "c", Schema: "public", Columns: []model.Column{{Name: "id"}}},
+	}
+	edges, _ := foreignKeyEdges(tables)
+
+	_, err := parentFirstOrder(tables, edges)
+	if err == nil || !strings.Contains(err.Error(), "public.a, public.b form a cycle") {
+		t.Errorf("parentFirstOrder() error = %v; want a cycle between public.a and public.b", err)
+	}
+}
+
+func TestParentFirstRows(t *testing.T) {
+	edges := []foreignKeyEdge{{child: 0, parent: 0, column: "manager_id", refColumn: "id"}}
+	rows := []adapter.SelectedRow{
+		{ID: "(3,2)", Keys: map[string]string{"id": "3", "manager_id": "2"}},
+		{ID: "(2,1)", Keys: map[string]string{"id": "2", "manager_id": "1"}},
+		{ID: "(4,4)", Keys: map[string]string{"id": "4", "manager_id": "4"}},
+		{ID: "(1,)", Keys: map[string]string{"id": "1"}},
+		{ID: "(5,9)", Keys: map[string]string{"id": "5", "manager_id": "9"}},
+	}
+
+	ordered, err := parentFirstRows(rows, edges)
+	if err != nil {
+		t.Fatalf("parentFirstRows() unexpected error: %v", err)
+	}
+
+	var ids []string
+	for _, row := range ordered {
+		ids = append(ids, row.ID)
+	}
+	expected := []string{"(1,)", "(2,1)", "(3,2)", "(4,4)", "(5,9)"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("parentFirstRows() = %v; want %v", ids, expected)
+	}
+
+	cyclic := []adapter.SelectedRow{
+		{ID: "(1,2)", Keys: map[string]string{"id": "1", "manager_id": "2"}},
+		{ID: "(2,1)", Keys: map[string]string{"id": "2", "manager_id": "1"}},
+	}
+	if _, err := parentFirstRows(cyclic, edges); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("parentFirstRows() error = %v; want a cycle", err)
+	}
+}
+
+func TestSelectionAdd(t *testing.T) {
+	s := selection{indexes: make(map[string]int), followed: make(map[string]bool)}
+	rows := []adapter.SelectedRow{{ID: "(1)"}, {ID: "(2)"}}
+
+	if added := s.add(rows, false); len(added) != 2 {
+		t.Errorf("add() = %v; want both rows added", added)
+	}
+	if added := s.add(rows[:1], false); len(added) != 0 {
+		t.Errorf("add() = %v; want no rows added again", added)
+	}
+	if added := s.add(rows[:1], true); len(added) != 1 {
+		t.Errorf("add() = %v; want the row returned to be followed", added)
+	}
+	if added := s.add(rows[:1], true); len(added) != 0 {
+		t.Errorf("add() = %v; want the followed row not returned again", added)
+	}
+	if len(s.rows) != 2 {
+		t.Errorf("selection rows = %v; want 2 rows", s.rows)
+	}
+}
@@ -0,0 +1,154 @@
+package action
+
+import (
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+const batchSavepoint = "dbaker_batch"
+
+// transaction wraps the writes of a run into transactions by the transaction mode. Batches of rows are either
+// transactions on their own (batch mode) or marked by savepoints, a dry run rolls back the failed batch only
+// and carries on to report every failing table.
+type transaction struct {
+	adapter   *adapter.PostgreSQLAdapter
+	mode      config.TxMode
+	dryRun    bool
+	batchSize uint32
+	// a savepoint of the current table was set
+	savepoint bool
+}
+
+func newTransaction(adapter *adapter.PostgreSQLAdapter, cfg config.Config) (*transaction, error) {
+	mode := cfg.Transaction
+	if mode == "" {
+		mode = config.TxNone
+	}
+	if !slices.Contains([]config.TxMode{config.TxNone, config.TxRun, config.TxTable, config.TxBatch}, mode) {
+		return nil, fmt.Errorf("unknown transaction mode '%s', expected one of none, run, table, batch", mode)
+	}
+
+	// a dry run wraps the whole run so that it can be rolled back
+	if cfg.DryRun {
+		mode = config.TxRun
+	}
+
+	// rows written without transactions may be committed after the last recorded progress,
+	// a resumed run would write them twice
+	if cfg.StatePath != "" && mode == config.TxNone {
+		return nil, errors.New("the state of a run is recorded within transactions only, use --tx batch (or table, run) along with --state")
+	}
+
+	batchSize := cfg.BatchSize
+	if batchSize == 0 {
+		batchSize = 1000
+	}
+
+	return &transaction{adapter, mode, cfg.DryRun, batchSize, false}, nil
+}
+
+func (t *transaction) beginRun() error {
+	if t.mode != config.TxRun {
+		return nil
+	}
+	return t.adapter.Begin()
+}
+
+// beginCleanup opens the transaction the data of previous runs is removed in: in table and batch mode
+// it is the transaction of the first table or batch, so that their failure rolls the removal back too
+func (t *transaction) beginCleanup() error {
+	if t.mode != config.TxTable && t.mode != config.TxBatch {
+		return nil
+	}
+	return t.adapter.Begin()
+}
+
+func (t *transaction) beginTable() error {
+	t.savepoint = false
+	// the transaction of the cleanup is taken over
+	if t.mode != config.TxTable || t.adapter.InTransaction() {
+		return nil
+	}
+	return t.adapter.Begin()
+}
+
+// batch starts the next batch when the row (index within the rows of the table written by this run) starts one,
+// by committing the previous batch in batch mode, by a savepoint otherwise
+func (t *transaction) batch(index uint32) error {
+	if t.mode == config.TxNone || index%t.batchSize != 0 {
+		return nil
+	}
+
+	if t.mode == config.TxBatch {
+		if index > 0 {
+			if err := t.adapter.Commit(); err != nil {
+				return err
+			}
+		}
+		// the transaction of the cleanup is taken over
+		if t.adapter.InTransaction() {
+			return nil
+		}
+		return t.adapter.Begin()
+	}
+
+	if t.savepoint {
+		if err := t.adapter.ReleaseSavepoint(batchSavepoint); err != nil {
+			return err
+		}
+	}
+
+	t.savepoint = true
+	return t.adapter.Savepoint(batchSavepoint)
+}
+
+// endTable commits the table transaction, on error the open transaction is rolled back,
+// a dry run only rolls back the failed batch
+func (t *transaction) endTable(err error) error {
+	switch {
+	case err != nil && t.dryRun:
+		if t.savepoint {
+			return errors.Join(err, t.adapter.RollbackToSavepoint(batchSavepoint))
+		}
+		return err
+	case err != nil:
+		return errors.Join(err, t.adapter.Rollback())
+	case t.mode == config.TxTable || t.mode == config.TxBatch && t.adapter.InTransaction():
+		return t.adapter.Commit()
+	default:
+		return nil
+	}
+}
+
+// endRun commits the run transaction, a dry run is always rolled back. A transaction left open in the other modes
+// (e.g. the one of the cleanup) is committed, or rolled back on error.
+func (t *transaction) endRun(err error) error {
+	if t.mode != config.TxRun {
+		if err != nil {
+			return errors.Join(err, t.adapter.Rollback())
+		}
+		if t.adapter.InTransaction() {
+			return t.adapter.Commit()
+		}
+		return nil
+	}
+
+	if t.dryRun || err != nil {
+		return errors.Join(err, t.adapter.Rollback())
+	}
+	return t.adapter.Commit()
+}
+
+// commitsBatch reports whether the rows before the row (index within the rows of the table written by this run)
+// were committed when its batch started
+func (t *transaction) commitsBatch(index uint32) bool {
+	return t.mode == config.TxBatch && index > 0 && index%t.batchSize == 0
+}
+
+// commitsTables reports whether the rows of a table are committed when the table ends
+func (t *transaction) commitsTables() bool {
+	return t.mode != config.TxRun
+}
@@ -0,0 +1,92 @@
+package action
+
+import (
+	"dbaker/pkg/adapter"
+	"dbaker/pkg/config"
+	"testing"
+)
+
+func TestNewTransaction(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        config.Config
+		wantMode      config.TxMode
+		wantBatchSize uint32
+		wantErr       bool
+	}{
+		{name: "defaults", config: config.Config{}, wantMode: config.TxNone, wantBatchSize: 1000},
+		{name: "table mode", config: config.Config{Transaction: config.TxTable, BatchSize: 50}, wantMode: config.TxTable, wantBatchSize: 50},
+		{name: "dry run wraps the run", config: config.Config{Transaction: config.TxTable, DryRun: true}, wantMode: config.TxRun, wantBatchSize: 1000},
+		{name: "batch mode", config: config.Config{Transaction: config.TxBatch}, wantMode: config.TxBatch, wantBatchSize: 1000},
+		{name: "unknown mode", config: config.Config{Transaction: "row"}, wantErr: true},
+		{name: "state without transactions", config: config.Config{StatePath: "seed.state.json"}, wantErr: true},
+		{name: "state of batches", config: config.Config{Transaction: config.TxBatch, StatePath: "seed.state.json"}, wantMode: config.TxBatch, wantBatchSize: 1000},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pgAdapter := adapter.NewPostgreSQLAdapter(tc.config)
+			tx, err := newTransaction(&pgAdapter, tc.config)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("newTransaction() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newTransaction() unexpected error: %v", err)
+			}
+
+			if tx.mode != tc.wantMode || tx.batchSize != tc.wantBatchSize {
+				t.Errorf("newTransaction() = %s, %d; want %s, %d", tx.mode, tx.batchSize, tc.wantMode, tc.wantBatchSize)
+			}
+		})
+	}
+}
+
+func TestTransactionWithoutModeSkipsSavepoints(t *testing.T) {
+	pgAdapter := adapter.NewPostgreSQLAdapter(config.Config{})
+	tx, err := newTransaction(&pgAdapter, config.Config{})
+	if err != nil {
+		t.Fatalf("newTransaction() unexpected error: %v", err)
+	}
+
+	// no transaction is opened, so neither of these may touch the database
+	if err := tx.beginRun(); err != nil {
+		t.Errorf("beginRun() unexpected error: %v", err)
+	}
+	if err := tx.beginTable(); err != nil {
+		t.Errorf("beginTable() unexpected error: %v", err)
+	}
+	if err := tx.batch(0); err != nil {
+		t.Errorf("batch() unexpected error: %v", err)
+	}
+	if err := tx.endTable(nil); err != nil {
+		t.Errorf("endTable() unexpected error: %v", err)
+	}
+	if err := tx.endRun(nil); err != nil {
+		t.Errorf("endRun() unexpected error: %v", err)
+	}
+}
+
+func TestTransactionCommitsBatch(t *testing.T) {
+	testCases := []struct {
+		mode  config.TxMode
+		index uint32
+		want  bool
+	}{
+		{mode: config.TxBatch, index: 0, want: false},
+		{mode: config.TxBatch, index: 99, want: false},
+		{mode: config.TxBatch, index: 100, want: true},
+		{mode: config.TxBatch, index: 300, want: true},
+		{mode: config.TxTable, index: 100, want: false},
+		{mode: config.TxNone, index: 100, want: false},
+	}
+
+	for _, tc := range testCases {
+		tx := &transaction{mode: tc.mode, batchSize: 100}
+		if got := tx.commitsBatch(tc.index); got != tc.want {
+			t.Errorf("commitsBatch(%d) in %s mode = %t; want %t", tc.index, tc.mode, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,560 @@
+package adapter
+
+import (
+	"dbaker/pkg/model"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrCheckNotSupported = errors.New("check constraint not supported")
+)
+
+// columnConstraint binds a parsed check condition to the column it restricts
+type columnConstraint struct {
+	Column     string
+	Constraint model.Constraint
+}
+
+// parseCheckConstraint parses the output of pg_get_constraintdef for CHECK constraints.
+// Supported are conjunctions (AND) of comparisons, BETWEEN, IN lists (incl. PostgreSQL's
+// `= ANY (ARRAY[...])` rewrite), length checks and comparisons between two columns.
+func parseCheckConstraint(def string) ([]columnConstraint, error) {
+	def = strings.TrimSpace(def)
+	def = strings.TrimSuffix(def, "NOT VALID")
+	def = strings.TrimSpace(def)
+	if len(def) < 5 || !strings.EqualFold(def[:5], "check") {
+		return nil, fmt.Errorf("%w: not a check constraint: %s", ErrCheckNotSupported, def)
+	}
+
+	tokens, err := tokenizeCheck(def[5:])
+	if err != nil {
+		return nil, err
+	}
+
+	parser := checkParser{tokens: tokens}
+	constraints, err := parser.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if !parser.done() {
+		return nil, fmt.Errorf("%w: unexpected token '%s'", ErrCheckNotSupported, parser.peek().text)
+	}
+
+	return constraints, nil
+}
+
+type checkTokenKind int
+
+const (
+	tokIdent checkTokenKind = iota
+	tokNumber
+	tokString
+	tokSymbol
+)
+
+type checkToken struct {
+	kind checkTokenKind
+	text string
+}
+
+func tokenizeCheck(input string) ([]checkToken, error) {
+	var tokens []checkToken
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'':
+			builder := strings.Builder{}
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("%w: unterminated string literal", ErrCheckNotSupported)
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						builder.WriteRune('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				builder.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, checkToken{tokString, builder.String()})
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("%w: unterminated quoted identifier", ErrCheckNotSupported)
+			}
+			tokens = append(tokens, checkToken{tokIdent, string(runes[i+1 : end])})
+			i = end + 1
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			end := i
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.' || runes[end] == 'e' || runes[end] == 'E') {
+				end++
+			}
+			tokens = append(tokens, checkToken{tokNumber, string(runes[i:end])})
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_' || runes[end] == '$') {
+				end++
+			}
+			tokens = append(tokens, checkToken{tokIdent, strings.ToLower(string(runes[i:end]))})
+			i = end
+		default:
+			symbol := string(r)
+			if i+1 < len(runes) {
+				switch pair := string(runes[i : i+2]); pair {
+				case ">=", "<=", "<>", "!=", "::":
+					symbol = pair
+				}
+			}
+			if !strings.Contains("()[],=<>!:-+", symbol[:1]) {
+				return nil, fmt.Errorf("%w: unexpected character '%s'", ErrCheckNotSupported, symbol)
+			}
+			tokens = append(tokens, checkToken{tokSymbol, symbol})
+			i += len(symbol)
+		}
+	}
+
+	return tokens, nil
+}
+
+type checkParser struct {
+	tokens []checkToken
+	pos    int
+}
+
+// checkOperand is one side of a comparison
+type checkOperand struct {
+	column string
+	length bool
+	value  string
+	values []string
+	isList bool
+}
+
+func (o checkOperand) isColumn() bool {
+	return o.column != ""
+}
+
+func (p *checkParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *checkParser) peek() checkToken {
+	if p.done() {
+		return checkToken{tokSymbol, ""}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *checkParser) next() checkToken {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *checkParser) isSymbol(text string) bool {
+	token := p.peek()
+	return token.kind == tokSymbol && token.text == text
+}
+
+func (p *checkParser) isKeyword(text string) bool {
+	token := p.peek()
+	return token.kind == tokIdent && token.text == text
+}
+
+func (p *checkParser) expectSymbol(text string) error {
+	if !p.isSymbol(text) {
+		return fmt.Errorf("%w: expected '%s', got '%s'", ErrCheckNotSupported, text, p.peek().text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *checkParser) parseExpr() ([]columnConstraint, error) {
+	constraints, err := p.parseConjunct()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("and") {
+		p.pos++
+		next, err := p.parseConjunct()
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, next...)
+	}
+
+	if p.isKeyword("or") {
+		return nil, fmt.Errorf("%w: disjunctions (OR) are not supported", ErrCheckNotSupported)
+	}
+
+	return constraints, nil
+}
+
+func (p *checkParser) parseConjunct() ([]columnConstraint, error) {
+	// a parenthesis opens either a nested expression or an operand like (price)::numeric
+	if !p.isSymbol("(") {
+		return p.parsePredicate()
+	}
+
+	start := p.pos
+	p.pos++
+	constraints, nestedErr := p.parseExpr()
+	if nestedErr == nil && p.isSymbol(")") {
+		p.pos++
+		if p.done() || p.isSymbol(")") || p.isKeyword("and") || p.isKeyword("or") {
+			return constraints, nil
+		}
+	}
+
+	p.pos = start
+	constraints, err := p.parsePredicate()
+	if err != nil && nestedErr != nil {
+		return nil, nestedErr
+	}
+
+	return constraints, err
+}
+
+func (p *checkParser) parsePredicate() ([]columnConstraint, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.isKeyword("is"):
+		// IS [NOT] NULL does not restrict generated values any further
+		p.pos++
+		if p.isKeyword("not") {
+			p.pos++
+		}
+		if !p.isKeyword("null") {
+			return nil, fmt.Errorf("%w: unsupported IS predicate", ErrCheckNotSupported)
+		}
+		p.pos++
+		return nil, nil
+
+	case p.isKeyword("between"):
+		p.pos++
+		low, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isKeyword("and") {
+			return nil, fmt.Errorf("%w: expected AND in BETWEEN", ErrCheckNotSupported)
+		}
+		p.pos++
+		high, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		lowConstraint, err := comparison(left, model.OpGte, low)
+		if err != nil {
+			return nil, err
+		}
+		highConstraint, err := comparison(left, model.OpLte, high)
+		if err != nil {
+			return nil, err
+		}
+		return []columnConstraint{lowConstraint, highConstraint}, nil
+
+	case p.isKeyword("in"):
+		p.pos++
+		list, err := p.parseList("(", ")")
+		if err != nil {
+			return nil, err
+		}
+		constraint, err := comparison(left, model.OpIn, checkOperand{values: list, isList: true})
+		if err != nil {
+			return nil, err
+		}
+		return []columnConstraint{constraint}, nil
+
+	case p.isKeyword("not"):
+		return nil, fmt.Errorf("%w: negated predicates are not supported", ErrCheckNotSupported)
+	}
+
+	opToken := p.next()
+	op, ok := mapCheckOperator(opToken.text)
+	if opToken.kind != tokSymbol || !ok {
+		return nil, fmt.Errorf("%w: unsupported operator '%s'", ErrCheckNotSupported, opToken.text)
+	}
+
+	// x = ANY (ARRAY[...]) is how PostgreSQL stores x IN (...)
+	if p.isKeyword("any") && op == model.OpEq {
+		p.pos++
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		if !right.isList {
+			return nil, fmt.Errorf("%w: expected an array after ANY", ErrCheckNotSupported)
+		}
+		constraint, err := comparison(left, model.OpIn, right)
+		if err != nil {
+			return nil, err
+		}
+		return []columnConstraint{constraint}, nil
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	constraint, err := comparison(left, op, right)
+	if err != nil {
+		return nil, err
+	}
+
+	return []columnConstraint{constraint}, nil
+}
+
+func (p *checkParser) parseOperand() (checkOperand, error) {
+	operand, err := p.parseTerm()
+	if err != nil {
+		return checkOperand{}, err
+	}
+
+	// casts do not change the generated value, skip them (e.g. ::text, ::character varying[])
+	for p.isSymbol("::") {
+		p.pos++
+		if err := p.skipTypeName(); err != nil {
+			return checkOperand{}, err
+		}
+	}
+
+	return operand, nil
+}
+
+func (p *checkParser) parseTerm() (checkOperand, error) {
+	token := p.next()
+	switch token.kind {
+	case tokNumber:
+		return checkOperand{value: token.text}, nil
+	case tokString:
+		if strings.HasPrefix(token.text, "{") && strings.HasSuffix(token.text, "}") {
+			// array literal, e.g. '{a,b}'::text[]
+			var values []string
+			for _, value := range strings.Split(token.text[1:len(token.text)-1], ",") {
+				values = append(values, strings.Trim(value, `"`))
+			}
+			return checkOperand{values: values, isList: true}, nil
+		}
+		return checkOperand{value: token.text}, nil
+	case tokSymbol:
+		switch token.text {
+		case "-", "+":
+			operand, err := p.parseTerm()
+			if err != nil {
+				return checkOperand{}, err
+			}
+			if operand.isColumn() || operand.isList {
+				return checkOperand{}, fmt.Errorf("%w: unsupported arithmetic expression", ErrCheckNotSupported)
+			}
+			if token.text == "-" {
+				operand.value = "-" + operand.value
+			}
+			return operand, nil
+		case "(":
+			operand, err := p.parseOperand()
+			if err != nil {
+				return checkOperand{}, err
+			}
+			if err := p.expectSymbol(")"); err != nil {
+				return checkOperand{}, err
+			}
+			return operand, nil
+		}
+	case tokIdent:
+		switch token.text {
+		case "array":
+			list, err := p.parseList("[", "]")
+			if err != nil {
+				return checkOperand{}, err
+			}
+			return checkOperand{values: list, isList: true}, nil
+		case "current_date", "current_timestamp", "localtimestamp", "current_time", "localtime":
+			return checkOperand{value: model.NowLiteral}, nil
+		case "true", "false":
+			return checkOperand{value: token.text}, nil
+		}
+
+		if !p.isSymbol("(") {
+			return checkOperand{column: token.text}, nil
+		}
+
+		// function call
+		p.pos++
+		switch token.text {
+		case "now", "statement_timestamp", "transaction_timestamp", "clock_timestamp":
+			if err := p.expectSymbol(")"); err != nil {
+				return checkOperand{}, err
+			}
+			return checkOperand{value: model.NowLiteral}, nil
+		case "length", "char_length", "character_length", "octet_length":
+			argument, err := p.parseOperand()
+			if err != nil {
+				return checkOperand{}, err
+			}
+			if err := p.expectSymbol(")"); err != nil {
+				return checkOperand{}, err
+			}
+			if !argument.isColumn() || argument.length {
+				return checkOperand{}, fmt.Errorf("%w: length of an expression", ErrCheckNotSupported)
+			}
+			argument.length = true
+			return argument, nil
+		}
+		return checkOperand{}, fmt.Errorf("%w: unsupported function '%s'", ErrCheckNotSupported, token.text)
+	}
+
+	return checkOperand{}, fmt.Errorf("%w: unexpected token '%s'", ErrCheckNotSupported, token.text)
+}
+
+func (p *checkParser) parseList(open string, close string) ([]string, error) {
+	if err := p.expectSymbol(open); err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for !p.isSymbol(close) {
+		operand, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if operand.isColumn() || operand.isList {
+			return nil, fmt.Errorf("%w: list items must be literals", ErrCheckNotSupported)
+		}
+		values = append(values, operand.value)
+
+		if p.isSymbol(",") {
+			p.pos++
+		} else if !p.isSymbol(close) {
+			return nil, fmt.Errorf("%w: expected ',' or '%s'", ErrCheckNotSupported, close)
+		}
+	}
+	p.pos++
+
+	return values, nil
+}
+
+func (p *checkParser) skipTypeName() error {
+	if p.peek().kind != tokIdent {
+		return fmt.Errorf("%w: expected type name", ErrCheckNotSupported)
+	}
+	for p.peek().kind == tokIdent && !p.isKeyword("and") && !p.isKeyword("or") &&
+		!p.isKeyword("between") && !p.isKeyword("in") && !p.isKeyword("is") && !p.isKeyword("not") {
+		p.pos++
+	}
+
+	// type modifiers, e.g. numeric(10,2) or varchar[]
+	if p.isSymbol("(") {
+		for !p.done() && !p.isSymbol(")") {
+			p.pos++
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return err
+		}
+	}
+	for p.isSymbol("[") {
+		p.pos++
+		if err := p.expectSymbol("]"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func mapCheckOperator(op string) (model.ConstraintOp, bool) {
+	switch op {
+	case ">":
+		return model.OpGt, true
+	case ">=":
+		return model.OpGte, true
+	case "<":
+		return model.OpLt, true
+	case "<=":
+		return model.OpLte, true
+	case "=":
+		return model.OpEq, true
+	case "<>", "!=":
+		return model.OpNe, true
+	default:
+		return "", false
+	}
+}
+
+// flipOperator mirrors the operator so that the column can be moved to the left side
+func flipOperator(op model.ConstraintOp) model.ConstraintOp {
+	switch op {
+	case model.OpGt:
+		return model.OpLt
+	case model.OpGte:
+		return model.OpLte
+	case model.OpLt:
+		return model.OpGt
+	case model.OpLte:
+		return model.OpGte
+	default:
+		return op
+	}
+}
+
+func comparison(left checkOperand, op model.ConstraintOp, right checkOperand) (columnConstraint, error) {
+	if !left.isColumn() && right.isColumn() && !right.isList {
+		left, right = right, left
+		op = flipOperator(op)
+	}
+
+	if !left.isColumn() {
+		return columnConstraint{}, fmt.Errorf("%w: comparison without a column", ErrCheckNotSupported)
+	}
+
+	constraint := model.Constraint{
+		Op:       op,
+		OnLength: left.length,
+	}
+
+	switch {
+	case right.isList:
+		if op != model.OpIn {
+			return columnConstraint{}, fmt.Errorf("%w: list used with operator '%s'", ErrCheckNotSupported, op)
+		}
+		constraint.Values = right.values
+	case right.isColumn():
+		if left.length || right.length {
+			return columnConstraint{}, fmt.Errorf("%w: length compared with a column", ErrCheckNotSupported)
+		}
+		constraint.Column = right.column
+	default:
+		constraint.Value = right.value
+	}
+
+	return columnConstraint{Column: left.column, Constraint: constraint}, nil
+}
@@ -0,0 +1,154 @@
+package adapter
+
+import (
+	"dbaker/pkg/model"
+	"reflect"
+	"testing"
+)
+
+func TestParseCheckConstraint(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		expected   []columnConstraint
+		wantErr    bool
+	}{
+		{
+			name:       "comparison with numeric literal",
+			definition: "CHECK ((price > (0)::numeric))",
+			expected: []columnConstraint{
+				{Column: "price", Constraint: model.Constraint{Op: model.OpGt, Value: "0"}},
+			},
+		},
+		{
+			name:       "literal on the left side",
+			definition: "CHECK ((0 <= amount))",
+			expected: []columnConstraint{
+				{Column: "amount", Constraint: model.Constraint{Op: model.OpGte, Value: "0"}},
+			},
+		},
+		{
+			name:       "negative literal",
+			definition: "CHECK ((delta >= '-10'::integer))",
+			expected: []columnConstraint{
+				{Column: "delta", Constraint: model.Constraint{Op: model.OpGte, Value: "-10"}},
+			},
+		},
+		{
+			name:       "between rewritten by postgres",
+			definition: "CHECK (((age >= 18) AND (age <= 65)))",
+			expected: []columnConstraint{
+				{Column: "age", Constraint: model.Constraint{Op: model.OpGte, Value: "18"}},
+				{Column: "age", Constraint: model.Constraint{Op: model.OpLte, Value: "65"}},
+			},
+		},
+		{
+			name:       "between",
+			definition: "CHECK (age BETWEEN 18 AND 65)",
+			expected: []columnConstraint{
+				{Column: "age", Constraint: model.Constraint{Op: model.OpGte, Value: "18"}},
+				{Column: "age", Constraint: model.Constraint{Op: model.OpLte, Value: "65"}},
+			},
+		},
+		{
+			name:       "in list rewritten by postgres",
+			definition: "CHECK (((status)::text = ANY ((ARRAY['a'::character varying, 'b'::character varying])::text[])))",
+			expected: []columnConstraint{
+				{Column: "status", Constraint: model.Constraint{Op: model.OpIn, Values: []string{"a", "b"}}},
+			},
+		},
+		{
+			name:       "in list",
+			definition: "CHECK (status IN ('a', 'b', 'c'))",
+			expected: []columnConstraint{
+				{Column: "status", Constraint: model.Constraint{Op: model.OpIn, Values: []string{"a", "b", "c"}}},
+			},
+		},
+		{
+			name:       "length check",
+			definition: "CHECK ((length((name)::text) >= 3))",
+			expected: []columnConstraint{
+				{Column: "name", Constraint: model.Constraint{Op: model.OpGte, Value: "3", OnLength: true}},
+			},
+		},
+		{
+			name:       "cross column comparison",
+			definition: "CHECK ((end_at > start_at))",
+			expected: []columnConstraint{
+				{Column: "end_at", Constraint: model.Constraint{Op: model.OpGt, Column: "start_at"}},
+			},
+		},
+		{
+			name:       "quoted identifier and now",
+			definition: `CHECK (("createdAt" <= now()))`,
+			expected: []columnConstraint{
+				{Column: "createdAt", Constraint: model.Constraint{Op: model.OpLte, Value: model.NowLiteral}},
+			},
+		},
+		{
+			name:       "date literal",
+			definition: "CHECK ((born > '1900-01-01'::date)) NOT VALID",
+			expected: []columnConstraint{
+				{Column: "born", Constraint: model.Constraint{Op: model.OpGt, Value: "1900-01-01"}},
+			},
+		},
+		{
+			name:       "disjunction",
+			definition: "CHECK (((a > 1) OR (b > 1)))",
+			wantErr:    true,
+		},
+		{
+			name:       "arithmetic",
+			definition: "CHECK (((a + b) > 10))",
+			wantErr:    true,
+		},
+		{
+			name:       "unknown function",
+			definition: "CHECK ((lower(email) = email))",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseCheckConstraint(tt.definition)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCheckConstraint(%q) error = %v, wantErr %v", tt.definition, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("parseCheckConstraint(%q) = %+v; want %+v", tt.definition, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyChecks(t *testing.T) {
+	name := func(s string) *string { return &s }
+	columns := []model.Column{
+		{Name: "price", Typ: model.Int},
+		{Name: "status", Typ: model.Varchar},
+	}
+	checks := []PgCheckConstraint{
+		{ConstraintName: name("price_check"), Definition: name("CHECK ((price > 0))")},
+		{ConstraintName: name("status_check"), Definition: name("CHECK (((status)::text = lower((status)::text)))")},
+		{ConstraintName: name("other_check"), Definition: name("CHECK ((missing > 0))")},
+	}
+
+	unparsed := applyChecks(columns, checks)
+
+	expectedUnparsed := []string{
+		"status_check: CHECK (((status)::text = lower((status)::text)))",
+		"other_check: CHECK ((missing > 0))",
+	}
+	if !reflect.DeepEqual(unparsed, expectedUnparsed) {
+		t.Errorf("applyChecks() unparsed = %v; want %v", unparsed, expectedUnparsed)
+	}
+
+	expectedConstraints := []model.Constraint{{Op: model.OpGt, Value: "0"}}
+	if !reflect.DeepEqual(columns[0].Constraints, expectedConstraints) {
+		t.Errorf("applyChecks() price constraints = %v; want %v", columns[0].Constraints, expectedConstraints)
+	}
+	if len(columns[1].Constraints) != 0 {
+		t.Errorf("applyChecks() status constraints = %v; want none", columns[1].Constraints)
+	}
+}
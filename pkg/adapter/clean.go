@@ -0,0 +1,56 @@
+package adapter
+
+import (
+	"dbaker/pkg/model"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// TruncateTables empties the tables (in the schema they are written into) and resets their identities,
+// tables referencing them are truncated as well, so the order of the tables does not matter
+func (p *PostgreSQLAdapter) TruncateTables(tables []model.Table) error {
+	if len(tables) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(tables))
+	for index, table := range tables {
+		names[index] = quoteTable(table.WriteSchema(), table.Name)
+	}
+
+	truncateQuery := fmt.Sprintf("truncate table %s restart identity cascade;", strings.Join(names, ", "))
+	slog.Debug("truncating tables", "query", truncateQuery)
+	if _, err := p.writer().Exec(truncateQuery); err != nil {
+		return fmt.Errorf("failed to truncate tables: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRowsByKey deletes the rows of the table whose column holds one of the values (given as text),
+// returns the number of deleted rows
+func (p *PostgreSQLAdapter) DeleteRowsByKey(table model.Table, column model.Column, values []string) (int64, error) {
+	// casting the parameter rather than the column keeps the column indexes usable
+	deleteQuery := fmt.Sprintf("delete from %s where %s = any($1::text[]::%s[]);",
+		quoteTable(table.WriteSchema(), table.Name), quoteIdent(column.Name), castType(column.Typ))
+
+	slog.Debug("deleting rows", "query", deleteQuery, "values", len(values))
+
+	var deleted int64
+	for start := 0; start < len(values); start += selectChunkSize {
+		chunk := values[start:min(start+selectChunkSize, len(values))]
+		result, err := p.writer().Exec(deleteQuery, chunk)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete rows of table '%s.%s': %w", table.WriteSchema(), table.Name, err)
+		}
+
+		count, err := result.RowsAffected()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += count
+	}
+
+	return deleted, nil
+}
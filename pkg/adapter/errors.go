@@ -0,0 +1,88 @@
+package adapter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ConstraintViolationError reports a row the database rejected for violating a constraint (SQLSTATE class 23),
+// the message leaves the table to the caller reporting the row
+type ConstraintViolationError struct {
+	Table      string
+	Constraint string
+	// unique, foreign key, check, not null, exclusion or integrity
+	Kind   string
+	Column string
+	Detail string
+	Err    *pgconn.PgError
+}
+
+func (e *ConstraintViolationError) Error() string {
+	var message strings.Builder
+	fmt.Fprintf(&message, "row violates %s constraint", e.Kind)
+	if e.Constraint != "" {
+		fmt.Fprintf(&message, " '%s'", e.Constraint)
+	}
+	if e.Column != "" {
+		fmt.Fprintf(&message, " of column '%s'", e.Column)
+	}
+	if e.Detail != "" {
+		fmt.Fprintf(&message, ": %s", e.Detail)
+	}
+	if hint := e.Hint(); hint != "" {
+		fmt.Fprintf(&message, "\n  hint: %s", hint)
+	}
+	return message.String()
+}
+
+func (e *ConstraintViolationError) Unwrap() error {
+	return e.Err
+}
+
+// Hint suggests how to change the run or the recipe to satisfy the constraint
+func (e *ConstraintViolationError) Hint() string {
+	switch e.Kind {
+	case "unique":
+		return "the value exists already, remove the rows of the previous run (--truncate, --delete-generated) or append after them (--auto-iter, --iterFrom)"
+	case "foreign key":
+		return "the referenced row does not exist, generate the referenced table first or annotate the column with existing keys"
+	case "check":
+		return "add the check as column constraints to the recipe, or annotate the column with values satisfying it"
+	case "not null":
+		return "the column is missing from the recipe or has a null ratio set"
+	}
+	return ""
+}
+
+// constraintViolation turns a constraint violation reported by the database into a ConstraintViolationError,
+// other errors are returned as they are
+func constraintViolation(table string, err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || !strings.HasPrefix(pgErr.Code, "23") {
+		return err
+	}
+
+	kinds := map[string]string{
+		"23502": "not null",
+		"23503": "foreign key",
+		"23505": "unique",
+		"23514": "check",
+		"23P01": "exclusion",
+	}
+	kind, ok := kinds[pgErr.Code]
+	if !ok {
+		kind = "integrity"
+	}
+
+	return &ConstraintViolationError{
+		Table:      table,
+		Constraint: pgErr.ConstraintName,
+		Kind:       kind,
+		Column:     pgErr.ColumnName,
+		Detail:     pgErr.Detail,
+		Err:        pgErr,
+	}
+}
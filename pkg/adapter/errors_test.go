@@ -0,0 +1,58 @@
+package adapter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestConstraintViolation(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantKind    string
+		wantMessage string
+	}{
+		{
+			name:        "unique",
+			err:         &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key", Detail: "Key (email)=(a@b.c) already exists."},
+			wantKind:    "unique",
+			wantMessage: "row violates unique constraint 'users_email_key': Key (email)=(a@b.c) already exists.\n  hint: ",
+		},
+		{
+			name:        "not null",
+			err:         &pgconn.PgError{Code: "23502", ColumnName: "name"},
+			wantKind:    "not null",
+			wantMessage: "row violates not null constraint of column 'name'",
+		},
+		{
+			name:        "unknown integrity violation",
+			err:         &pgconn.PgError{Code: "23000"},
+			wantKind:    "integrity",
+			wantMessage: "row violates integrity constraint",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var violation *ConstraintViolationError
+			if !errors.As(constraintViolation("public.users", tt.err), &violation) {
+				t.Fatalf("constraintViolation() is not a ConstraintViolationError")
+			}
+			if violation.Kind != tt.wantKind || violation.Table != "public.users" {
+				t.Errorf("constraintViolation() kind = %s, table = %s; want %s, public.users", violation.Kind, violation.Table, tt.wantKind)
+			}
+			if !strings.HasPrefix(violation.Error(), tt.wantMessage) {
+				t.Errorf("Error() = %q; want prefix %q", violation.Error(), tt.wantMessage)
+			}
+		})
+	}
+
+	// other errors are passed through
+	other := &pgconn.PgError{Code: "42P01"}
+	if err := constraintViolation("public.users", other); err != other {
+		t.Errorf("constraintViolation() = %v; want the error itself", err)
+	}
+}
@@ -0,0 +1,42 @@
+package adapter
+
+import (
+	"dbaker/pkg/model"
+	"fmt"
+)
+
+// MaxValue returns the highest value of the column (in the schema the table is written into), nil for an
+// empty table. Text columns give their highest number matched by the first group of the pattern (POSIX regex),
+// as generated unique text holds the iteration.
+func (p *PostgreSQLAdapter) MaxValue(table model.Table, column model.Column, pattern string) (any, error) {
+	expression := quoteIdent(column.Name)
+	var args []any
+	if pattern != "" {
+		// up to 18 digits always fit into int8
+		expression = fmt.Sprintf("substring(%s from $1::text)::int8", expression)
+		args = append(args, pattern)
+	}
+
+	maxQuery := fmt.Sprintf("select max(%s) from %s;", expression, quoteTable(table.WriteSchema(), table.Name))
+	return p.maxValue(table, column, maxQuery, args...)
+}
+
+// MaxUUIDTime returns the highest unix millisecond timestamp of the version 7 uuids of the column,
+// nil when there are none. Uuids of other versions are left out.
+func (p *PostgreSQLAdapter) MaxUUIDTime(table model.Table, column model.Column) (any, error) {
+	text := quoteIdent(column.Name) + "::text"
+	// the timestamp is the first 48 bits, the version the first digit of the third group
+	expression := fmt.Sprintf("('x' || lpad(substr(replace(%s, '-', ''), 1, 12), 16, '0'))::bit(64)::int8", text)
+
+	maxQuery := fmt.Sprintf("select max(%s) from %s where substr(%s, 15, 1) = '7';", expression, quoteTable(table.WriteSchema(), table.Name), text)
+	return p.maxValue(table, column, maxQuery)
+}
+
+func (p *PostgreSQLAdapter) maxValue(table model.Table, column model.Column, maxQuery string, args ...any) (any, error) {
+	var value any
+	if err := p.db.QueryRow(maxQuery, args...).Scan(&value); err != nil {
+		return nil, fmt.Errorf("failed to find the highest value of column '%s' of table '%s.%s': %w", column.Name, table.WriteSchema(), table.Name, err)
+	}
+
+	return value, nil
+}
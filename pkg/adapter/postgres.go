@@ -3,43 +3,42 @@ package adapter
 import (
 	"database/sql"
 	"dbaker/pkg/config"
-	"dbaker/pkg/generator"
 	"dbaker/pkg/model"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
 	"strings"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
+var (
+	ErrTableNotFound = errors.New("table not found")
+)
+
 type PostgreSQLAdapter struct {
 	config config.Config
 	db     *sql.DB
-	gen    *generator.ValueGenerator
+	// open transaction the rows are written in, nil when writing outside of transactions
+	tx *sql.Tx
 }
 
 func NewPostgreSQLAdapter(config config.Config) PostgreSQLAdapter {
 	return PostgreSQLAdapter{
 		config: config,
 		db:     nil,
-		gen:    nil,
+		tx:     nil,
 	}
 }
 
 func (p *PostgreSQLAdapter) Init() error {
-	connection := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		p.config.Host,
-		p.config.Port,
-		p.config.Username,
-		p.config.Password,
-		p.config.Database,
-		p.config.SSLMode,
-	)
-
-	db, err := sql.Open("pgx", connection)
+	db, err := sql.Open("pgx", p.config.ConnectionString())
 	if err != nil {
 		return fmt.Errorf("failed to init a database connection: %w", err)
 	}
 
+	slog.Debug("database connection opened", "host", p.config.Host, "database", p.config.Database)
 	p.db = db
 	return nil
 }
@@ -50,6 +49,9 @@ func (p *PostgreSQLAdapter) Close() error {
 
 func (p *PostgreSQLAdapter) IntrospectTable(name string, schema string) (*model.Table, error) {
 	tbl, err := p.findTable(name, schema)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s.%s", ErrTableNotFound, schema, name)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to find table: %w", err)
 	}
@@ -64,6 +66,11 @@ func (p *PostgreSQLAdapter) IntrospectTable(name string, schema string) (*model.
 		return nil, fmt.Errorf("failed to find table constraints: %w", err)
 	}
 
+	checks, err := p.findTableChecks(name, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find table check constraints: %w", err)
+	}
+
 	var columns []model.Column
 	for _, infoSchemaColumn := range infoSchemaColumns {
 		column := infoSchemaColumn.mapToColumn()
@@ -78,10 +85,24 @@ func (p *PostgreSQLAdapter) IntrospectTable(name string, schema string) (*model.
 		columns = append(columns, column)
 	}
 
+	foreignKeys, err := p.findTableForeignKeys(name, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find table foreign keys: %w", err)
+	}
+
+	for _, foreignKey := range foreignKeys {
+		if index := findColumn(columns, *foreignKey.ColumnName); index >= 0 {
+			columns[index].ForeignKey = fmt.Sprintf("%s.%s.%s", *foreignKey.RefSchema, *foreignKey.RefTable, *foreignKey.RefColumn)
+		}
+	}
+
+	unparsedChecks := applyChecks(columns, checks)
+
 	table := model.Table{
-		Name:    *tbl.TableName,
-		Schema:  *tbl.TableSchema,
-		Columns: columns,
+		Name:           *tbl.TableName,
+		Schema:         *tbl.TableSchema,
+		Columns:        columns,
+		UnparsedChecks: unparsedChecks,
 	}
 	return &table, nil
 }
@@ -294,6 +315,191 @@ func (p *PostgreSQLAdapter) findTableConstraints(name string, schema string) ([]
 	return constraints, nil
 }
 
+const FIND_TABLE_CHECKS_BY_NAME_AND_SCHEMA_QUERY = `
+select
+	con.conname,
+	pg_get_constraintdef(con.oid)
+from
+	pg_catalog.pg_constraint as con
+join
+	pg_catalog.pg_class as rel
+on
+	rel.oid = con.conrelid
+join
+	pg_catalog.pg_namespace as nsp
+on
+	nsp.oid = rel.relnamespace
+where
+	con.contype = 'c'
+and
+	nsp.nspname = $1
+and
+	rel.relname = $2;
+`
+
+type PgCheckConstraint struct {
+	ConstraintName *string
+	Definition     *string
+}
+
+func (p *PostgreSQLAdapter) findTableChecks(name string, schema string) ([]PgCheckConstraint, error) {
+	statement, err := p.db.Prepare(FIND_TABLE_CHECKS_BY_NAME_AND_SCHEMA_QUERY)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare a query statement: %w", err)
+	}
+
+	rows, err := statement.Query(schema, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pg_constraint table: %w", err)
+	}
+	defer rows.Close()
+
+	var checks []PgCheckConstraint
+	for rows.Next() {
+		var check PgCheckConstraint
+		if err := rows.Scan(
+			&check.ConstraintName,
+			&check.Definition,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan check constraint: %w", err)
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+// composite foreign keys are unnested into column pairs
+const FIND_TABLE_FOREIGN_KEYS_BY_NAME_AND_SCHEMA_QUERY = `
+select
+	att.attname,
+	ref_nsp.nspname,
+	ref_rel.relname,
+	ref_att.attname
+from
+	pg_catalog.pg_constraint as con
+join
+	pg_catalog.pg_class as rel
+on
+	rel.oid = con.conrelid
+join
+	pg_catalog.pg_namespace as nsp
+on
+	nsp.oid = rel.relnamespace
+join
+	pg_catalog.pg_class as ref_rel
+on
+	ref_rel.oid = con.confrelid
+join
+	pg_catalog.pg_namespace as ref_nsp
+on
+	ref_nsp.oid = ref_rel.relnamespace
+cross join lateral
+	unnest(con.conkey, con.confkey) as keys(attnum, ref_attnum)
+join
+	pg_catalog.pg_attribute as att
+on
+	att.attrelid = con.conrelid
+and
+	att.attnum = keys.attnum
+join
+	pg_catalog.pg_attribute as ref_att
+on
+	ref_att.attrelid = con.confrelid
+and
+	ref_att.attnum = keys.ref_attnum
+where
+	con.contype = 'f'
+and
+	nsp.nspname = $1
+and
+	rel.relname = $2;
+`
+
+type PgForeignKey struct {
+	ColumnName *string
+	RefSchema  *string
+	RefTable   *string
+	RefColumn  *string
+}
+
+func (p *PostgreSQLAdapter) findTableForeignKeys(name string, schema string) ([]PgForeignKey, error) {
+	statement, err := p.db.Prepare(FIND_TABLE_FOREIGN_KEYS_BY_NAME_AND_SCHEMA_QUERY)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare a query statement: %w", err)
+	}
+
+	rows, err := statement.Query(schema, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pg_constraint table: %w", err)
+	}
+	defer rows.Close()
+
+	var foreignKeys []PgForeignKey
+	for rows.Next() {
+		var foreignKey PgForeignKey
+		if err := rows.Scan(
+			&foreignKey.ColumnName,
+			&foreignKey.RefSchema,
+			&foreignKey.RefTable,
+			&foreignKey.RefColumn,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan foreign key: %w", err)
+		}
+
+		foreignKeys = append(foreignKeys, foreignKey)
+	}
+
+	return foreignKeys, nil
+}
+
+// applyChecks attaches the parsed check constraints to their columns,
+// definitions that can't be parsed (or refer to unknown columns) are returned as-is
+func applyChecks(columns []model.Column, checks []PgCheckConstraint) []string {
+	var unparsed []string
+	for _, check := range checks {
+		definition := fmt.Sprintf("%s: %s", *check.ConstraintName, *check.Definition)
+
+		parsed, err := parseCheckConstraint(*check.Definition)
+		if err != nil {
+			unparsed = append(unparsed, definition)
+			continue
+		}
+
+		var indexes []int
+		for _, constraint := range parsed {
+			index := findColumn(columns, constraint.Column)
+			if index < 0 || (constraint.Constraint.Column != "" && findColumn(columns, constraint.Constraint.Column) < 0) {
+				indexes = nil
+				break
+			}
+			indexes = append(indexes, index)
+		}
+
+		if len(indexes) != len(parsed) {
+			unparsed = append(unparsed, definition)
+			continue
+		}
+
+		for i, constraint := range parsed {
+			columns[indexes[i]].Constraints = append(columns[indexes[i]].Constraints, constraint.Constraint)
+		}
+	}
+
+	return unparsed
+}
+
+func findColumn(columns []model.Column, name string) int {
+	for index, column := range columns {
+		if column.Name == name {
+			return index
+		}
+	}
+
+	return -1
+}
+
 func isUnique(column model.Column, constraint InfoSchemaConstraint) bool {
 	return column.Name == *constraint.ColumnName &&
 		(*constraint.ConstraintType == "UNIQUE" ||
@@ -303,21 +509,20 @@ func isUnique(column model.Column, constraint InfoSchemaConstraint) bool {
 // no batch support yet
 // generated values (infered, identities etc should not be present at this point)
 // insert into <schema>.<table> (<for-earch column.Name>,) values (for-each column '?')
-func (p *PostgreSQLAdapter) WriteRow(table string, schema string, columns []model.Column, iter uint32) error {
+func (p *PostgreSQLAdapter) WriteRow(table string, schema string, columns []model.Column, columnValues []any) error {
 	columnNames := inferColNames(columns)
 	placeholders := inferPgValPlaceholders(len(columns))
 	insertQuery := fmt.Sprintf("insert into %s.%s (%s) values (%s);",
-		table, schema, columnNames, placeholders)
-
-	columnValues, err := p.gen.GenVals(columns, iter)
-	if err != nil {
-		return fmt.Errorf("failed to generate row values for iteration '%d': %w", iter, err)
+		schema, table, columnNames, placeholders)
+	// identity values are only written when copying rows (mask), generated rows leave them out
+	if slices.ContainsFunc(columns, func(column model.Column) bool { return column.IsGenerated }) {
+		insertQuery = fmt.Sprintf("insert into %s.%s (%s) overriding system value values (%s);",
+			schema, table, columnNames, placeholders)
 	}
 
-	fmt.Printf("Generated insert query: %s\n", insertQuery)
-	fmt.Printf("Generated values: %v\n", columnValues)
+	slog.Debug("inserting row", "query", insertQuery, "values", columnValues)
 
-	stmt, err := p.db.Prepare(insertQuery)
+	stmt, err := p.writer().Prepare(insertQuery)
 	if err != nil {
 		return fmt.Errorf("failed to prepare insert statement: %w", err)
 	}
@@ -325,13 +530,48 @@ func (p *PostgreSQLAdapter) WriteRow(table string, schema string, columns []mode
 
 	_, err = stmt.Exec(columnValues...)
 	if err != nil {
-		return fmt.Errorf("failed to insert data to table '%s.%s' on iteration '%d': %w",
-			schema, table, iter, err)
+		if violation := constraintViolation(schema+"."+table, err); violation != err {
+			return violation
+		}
+		return fmt.Errorf("failed to insert data to table '%s.%s': %w", schema, table, err)
 	}
 
 	return nil
 }
 
+// ReadRows reads all rows of the table and passes values of the given columns to fn one row at a time
+func (p *PostgreSQLAdapter) ReadRows(table string, schema string, columns []model.Column, fn func(values []any) error) error {
+	names := make([]string, len(columns))
+	for index, column := range columns {
+		names[index] = quoteIdent(column.Name)
+	}
+	selectQuery := fmt.Sprintf("select %s from %s;", strings.Join(names, ", "), quoteTable(schema, table))
+
+	rows, err := p.db.Query(selectQuery)
+	if err != nil {
+		return fmt.Errorf("failed to read table '%s.%s': %w", schema, table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for index := range values {
+			pointers[index] = &values[index]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return fmt.Errorf("failed to scan row of table '%s.%s': %w", schema, table, err)
+		}
+
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
 func inferColNames(columns []model.Column) string {
 	builder := strings.Builder{}
 	for index, column := range columns {
@@ -0,0 +1,27 @@
+package adapter
+
+import "fmt"
+
+const HAS_INSERT_PRIVILEGE_QUERY = `select has_table_privilege($1, 'INSERT');`
+
+// HasInsertPrivilege tells whether the database user may insert rows into the table
+func (p *PostgreSQLAdapter) HasInsertPrivilege(table string, schema string) (bool, error) {
+	var granted bool
+	if err := p.db.QueryRow(HAS_INSERT_PRIVILEGE_QUERY, quoteTable(schema, table)).Scan(&granted); err != nil {
+		return false, fmt.Errorf("failed to check the insert privilege on table '%s.%s': %w", schema, table, err)
+	}
+
+	return granted, nil
+}
+
+// HasRows tells whether the table holds any row
+func (p *PostgreSQLAdapter) HasRows(table string, schema string) (bool, error) {
+	existsQuery := fmt.Sprintf("select exists (select from %s);", quoteTable(schema, table))
+
+	var exists bool
+	if err := p.db.QueryRow(existsQuery).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check for rows of table '%s.%s': %w", schema, table, err)
+	}
+
+	return exists, nil
+}
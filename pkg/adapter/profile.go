@@ -0,0 +1,274 @@
+package adapter
+
+import (
+	"dbaker/pkg/model"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const FIND_TABLE_ROW_ESTIMATE_QUERY = `
+select
+	rel.reltuples
+from
+	pg_catalog.pg_class as rel
+join
+	pg_catalog.pg_namespace as nsp
+on
+	nsp.oid = rel.relnamespace
+where
+	nsp.nspname = $1
+and
+	rel.relname = $2;
+`
+
+const FIND_TABLE_STATS_QUERY = `
+select
+	attname,
+	null_frac,
+	avg_width,
+	n_distinct,
+	histogram_bounds::text,
+	most_common_vals::text,
+	most_common_freqs::text
+from
+	pg_catalog.pg_stats
+where
+	schemaname = $1
+and
+	tablename = $2;
+`
+
+type PgStats struct {
+	ColumnName      *string
+	NullFraction    *float64
+	AvgWidth        *int
+	DistinctCount   *float64
+	HistogramBounds *string
+	MostCommonVals  *string
+	MostCommonFreqs *string
+}
+
+// ProfileTable samples statistics of the table data: the row count and per column null fractions,
+// distinct counts, min/max values and histograms. Statistics are read from pg_stats (populated by
+// ANALYZE), columns missing there are profiled by an aggregate query. No actual values are kept
+// except for the min/max, histogram bounds and most common values of numeric and temporal columns,
+// which the profile action rounds (see generator.CoarsenStats) unless exact values are asked for.
+func (p *PostgreSQLAdapter) ProfileTable(table *model.Table) error {
+	rowCount, err := p.countRows(table.Name, table.Schema)
+	if err != nil {
+		return fmt.Errorf("failed to count table rows: %w", err)
+	}
+	table.RowCount = uint32(min(rowCount, math.MaxUint32))
+
+	stats, err := p.findTableStats(table.Name, table.Schema)
+	if err != nil {
+		return fmt.Errorf("failed to find table statistics: %w", err)
+	}
+
+	for index := range table.Columns {
+		column := &table.Columns[index]
+
+		columnStats, ok := stats[column.Name]
+		if !ok {
+			if columnStats, err = p.aggregateColumnStats(table.Name, table.Schema, *column); err != nil {
+				return fmt.Errorf("failed to profile column '%s': %w", column.Name, err)
+			}
+		} else if isOrderable(column.Typ) && len(columnStats.Histogram) == 0 {
+			// pg_stats has no histogram for columns dominated by most common values, min/max still helps
+			if columnStats.Min, columnStats.Max, err = p.findColumnRange(table.Name, table.Schema, column.Name); err != nil {
+				return fmt.Errorf("failed to find range of column '%s': %w", column.Name, err)
+			}
+		}
+
+		if !isOrderable(column.Typ) {
+			columnStats.Histogram = nil
+			columnStats.MostCommon = nil
+		} else if len(columnStats.Histogram) > 0 {
+			columnStats.Min = columnStats.Histogram[0]
+			columnStats.Max = columnStats.Histogram[len(columnStats.Histogram)-1]
+		}
+
+		// negative n_distinct is the fraction of distinct values among all rows
+		if columnStats.DistinctCount < 0 {
+			columnStats.DistinctCount = math.Round(-columnStats.DistinctCount * float64(rowCount))
+		}
+
+		column.Stats = columnStats
+	}
+
+	return nil
+}
+
+func (p *PostgreSQLAdapter) countRows(name string, schema string) (uint64, error) {
+	var estimate float64
+	row := p.db.QueryRow(FIND_TABLE_ROW_ESTIMATE_QUERY, schema, name)
+	if err := row.Scan(&estimate); err != nil {
+		return 0, fmt.Errorf("failed to scan row estimate: %w", err)
+	}
+
+	// reltuples is -1 (or 0 on older versions) for tables which were never analyzed
+	if estimate > 0 {
+		return uint64(estimate), nil
+	}
+
+	var count uint64
+	row = p.db.QueryRow(fmt.Sprintf("select count(*) from %s;", quoteTable(schema, name)))
+	if err := row.Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to scan row count: %w", err)
+	}
+
+	return count, nil
+}
+
+func (p *PostgreSQLAdapter) findTableStats(name string, schema string) (map[string]*model.ColumnStats, error) {
+	statement, err := p.db.Prepare(FIND_TABLE_STATS_QUERY)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare a query statement: %w", err)
+	}
+
+	rows, err := statement.Query(schema, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pg_stats view: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make(map[string]*model.ColumnStats)
+	for rows.Next() {
+		var pgStats PgStats
+		if err := rows.Scan(
+			&pgStats.ColumnName,
+			&pgStats.NullFraction,
+			&pgStats.AvgWidth,
+			&pgStats.DistinctCount,
+			&pgStats.HistogramBounds,
+			&pgStats.MostCommonVals,
+			&pgStats.MostCommonFreqs,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan column statistics: %w", err)
+		}
+
+		stats[*pgStats.ColumnName] = pgStats.mapToColumnStats()
+	}
+
+	return stats, nil
+}
+
+func (s PgStats) mapToColumnStats() *model.ColumnStats {
+	var stats model.ColumnStats
+	if s.NullFraction != nil {
+		stats.NullFraction = *s.NullFraction
+	}
+	if s.AvgWidth != nil {
+		stats.AvgWidth = *s.AvgWidth
+	}
+	if s.DistinctCount != nil {
+		stats.DistinctCount = *s.DistinctCount
+	}
+	if s.HistogramBounds != nil {
+		stats.Histogram = parsePgArray(*s.HistogramBounds)
+	}
+	if s.MostCommonVals != nil && s.MostCommonFreqs != nil {
+		values, frequencies := parsePgArray(*s.MostCommonVals), parsePgArray(*s.MostCommonFreqs)
+		for index := range min(len(values), len(frequencies)) {
+			frequency, err := strconv.ParseFloat(frequencies[index], 64)
+			if err != nil {
+				continue
+			}
+			stats.MostCommon = append(stats.MostCommon, model.Category{Value: values[index], Weight: frequency})
+		}
+	}
+
+	return &stats
+}
+
+// aggregateColumnStats profiles a column which has no pg_stats entry (the table was never analyzed)
+func (p *PostgreSQLAdapter) aggregateColumnStats(name string, schema string, column model.Column) (*model.ColumnStats, error) {
+	query := fmt.Sprintf(`
+select
+	coalesce(avg((%[1]s is null)::int), 0),
+	coalesce(avg(pg_column_size(%[1]s)), 0)::int,
+	count(distinct %[1]s)
+from
+	%[2]s;`, quoteIdent(column.Name), quoteTable(schema, name))
+
+	var stats model.ColumnStats
+	if err := p.db.QueryRow(query).Scan(&stats.NullFraction, &stats.AvgWidth, &stats.DistinctCount); err != nil {
+		return nil, fmt.Errorf("failed to scan column aggregates: %w", err)
+	}
+
+	if isOrderable(column.Typ) {
+		var err error
+		if stats.Min, stats.Max, err = p.findColumnRange(name, schema, column.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	return &stats, nil
+}
+
+func (p *PostgreSQLAdapter) findColumnRange(name string, schema string, column string) (string, string, error) {
+	query := fmt.Sprintf("select min(%[1]s)::text, max(%[1]s)::text from %[2]s;", quoteIdent(column), quoteTable(schema, name))
+
+	var low, high *string
+	if err := p.db.QueryRow(query).Scan(&low, &high); err != nil {
+		return "", "", fmt.Errorf("failed to scan column range: %w", err)
+	}
+
+	if low == nil || high == nil {
+		return "", "", nil
+	}
+
+	return *low, *high, nil
+}
+
+// isOrderable reports whether min/max and histograms of the column type are meaningful to profile,
+// text columns are left out so that no actual values end up in the recipe
+func isOrderable(typ model.ColumnType) bool {
+	switch typ {
+	case model.SmallInt, model.Int, model.BigInt, model.Real, model.Double,
+		model.Date, model.Time, model.Timestamp, model.TimestampTZ:
+		return true
+	default:
+		return false
+	}
+}
+
+// parsePgArray parses the text representation of a one dimensional array, e.g. {1,"2024-01-01 10:00:00"}
+func parsePgArray(text string) []string {
+	text = strings.TrimPrefix(strings.TrimSuffix(text, "}"), "{")
+	if text == "" {
+		return nil
+	}
+
+	var values []string
+	builder := strings.Builder{}
+	quoted, escaped := false, false
+	for _, r := range text {
+		switch {
+		case escaped:
+			builder.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			values = append(values, builder.String())
+			builder.Reset()
+		default:
+			builder.WriteRune(r)
+		}
+	}
+
+	return append(values, builder.String())
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteTable(schema string, name string) string {
+	return quoteIdent(schema) + "." + quoteIdent(name)
+}
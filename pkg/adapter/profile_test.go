@@ -0,0 +1,36 @@
+package adapter
+
+import (
+	"dbaker/pkg/model"
+	"reflect"
+	"testing"
+)
+
+func TestParsePgArray(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"{}", nil},
+		{"{1,5,9}", []string{"1", "5", "9"}},
+		{`{"2024-01-01 10:00:00","2024-02-01 10:00:00"}`, []string{"2024-01-01 10:00:00", "2024-02-01 10:00:00"}},
+		{`{"a,b","c\"d"}`, []string{"a,b", `c"d`}},
+	}
+
+	for _, tt := range tests {
+		result := parsePgArray(tt.input)
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("parsePgArray(%q) = %q; want %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestMapToColumnStatsReadsMostCommonValues(t *testing.T) {
+	values, frequencies := "{1,2}", "{0.6,0.25}"
+	stats := PgStats{MostCommonVals: &values, MostCommonFreqs: &frequencies}.mapToColumnStats()
+
+	expected := []model.Category{{Value: "1", Weight: 0.6}, {Value: "2", Weight: 0.25}}
+	if !reflect.DeepEqual(stats.MostCommon, expected) {
+		t.Errorf("mapToColumnStats() most common = %+v; want %+v", stats.MostCommon, expected)
+	}
+}
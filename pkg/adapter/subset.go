@@ -0,0 +1,158 @@
+package adapter
+
+import (
+	"dbaker/pkg/model"
+	"fmt"
+	"strings"
+)
+
+// keys are looked up in chunks to keep the query parameters reasonably small
+const selectChunkSize = 10000
+
+// SelectedRow is a row picked into a subset
+type SelectedRow struct {
+	// text representation of the whole row, identifies the row within its table
+	ID string
+	// values of the table columns
+	Values []any
+	// text values of the requested key columns, NULLs are left out
+	Keys map[string]string
+}
+
+// SampleRows selects roughly the given percentage of the table rows
+func (p *PostgreSQLAdapter) SampleRows(table model.Table, percent float64, keyColumns []string) ([]SelectedRow, error) {
+	sample := fmt.Sprintf("tablesample bernoulli (%v)", percent)
+	return p.selectRows(table, sample, "true", nil, keyColumns)
+}
+
+// SelectRowsByKey selects rows of the table whose column holds one of the (text) values
+func (p *PostgreSQLAdapter) SelectRowsByKey(table model.Table, column string, values []string, keyColumns []string) ([]SelectedRow, error) {
+	index := findColumn(table.Columns, column)
+	if index < 0 {
+		return nil, fmt.Errorf("table '%s.%s' has no column '%s'", table.Schema, table.Name, column)
+	}
+
+	// casting the parameter rather than the column keeps the column indexes usable
+	condition := fmt.Sprintf("%s = any($1::text[]::%s[])", quoteIdent(column), castType(table.Columns[index].Typ))
+
+	var selected []SelectedRow
+	for start := 0; start < len(values); start += selectChunkSize {
+		chunk := values[start:min(start+selectChunkSize, len(values))]
+		rows, err := p.selectRows(table, "", condition, []any{chunk}, keyColumns)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, rows...)
+	}
+
+	return selected, nil
+}
+
+// QueryKeys runs a query selecting a single column, e.g. select id from customers where region = 'EU',
+// and returns the column name along with its values as text
+func (p *PostgreSQLAdapter) QueryKeys(query string) (string, []string, error) {
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+
+	rows, err := p.db.Query(fmt.Sprintf("select * from (%s) as q limit 0;", query))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to run the query: %w", err)
+	}
+	columns, err := rows.Columns()
+	rows.Close()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read the query columns: %w", err)
+	}
+	if len(columns) != 1 {
+		return "", nil, fmt.Errorf("the query must select exactly one column, got %d", len(columns))
+	}
+
+	rows, err = p.db.Query(fmt.Sprintf("select q.%s::text from (%s) as q;", quoteIdent(columns[0]), query))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to run the query: %w", err)
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value *string
+		if err := rows.Scan(&value); err != nil {
+			return "", nil, fmt.Errorf("failed to scan key: %w", err)
+		}
+		if value != nil {
+			values = append(values, *value)
+		}
+	}
+
+	return columns[0], values, rows.Err()
+}
+
+func (p *PostgreSQLAdapter) selectRows(table model.Table, sample string, condition string, args []any, keyColumns []string) ([]SelectedRow, error) {
+	selections := make([]string, 0, len(table.Columns)+len(keyColumns)+1)
+	for _, column := range table.Columns {
+		selections = append(selections, "t."+quoteIdent(column.Name))
+	}
+	for _, column := range keyColumns {
+		selections = append(selections, "t."+quoteIdent(column)+"::text")
+	}
+	selections = append(selections, "t::text")
+
+	query := fmt.Sprintf("select %s from %s as t %s where %s;",
+		strings.Join(selections, ", "), quoteTable(table.Schema, table.Name), sample, condition)
+
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select rows of table '%s.%s': %w", table.Schema, table.Name, err)
+	}
+	defer rows.Close()
+
+	var selected []SelectedRow
+	for rows.Next() {
+		values := make([]any, len(table.Columns))
+		keys := make([]*string, len(keyColumns))
+		var id string
+
+		pointers := make([]any, 0, len(values)+len(keys)+1)
+		for index := range values {
+			pointers = append(pointers, &values[index])
+		}
+		for index := range keys {
+			pointers = append(pointers, &keys[index])
+		}
+		pointers = append(pointers, &id)
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan row of table '%s.%s': %w", table.Schema, table.Name, err)
+		}
+
+		row := SelectedRow{ID: id, Values: values, Keys: make(map[string]string, len(keys))}
+		for index, key := range keys {
+			if key != nil {
+				row.Keys[keyColumns[index]] = *key
+			}
+		}
+		selected = append(selected, row)
+	}
+
+	return selected, rows.Err()
+}
+
+// castType maps the column type back onto the postgres type name
+func castType(typ model.ColumnType) string {
+	switch typ {
+	case model.SmallInt:
+		return "int2"
+	case model.BigInt:
+		return "int8"
+	case model.Real:
+		return "float4"
+	case model.Double:
+		return "float8"
+	case model.Decimal:
+		return "numeric"
+	case model.Char:
+		return "bpchar"
+	default:
+		// the remaining types are named after their udt name (unsupported types included)
+		return string(typ)
+	}
+}
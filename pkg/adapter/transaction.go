@@ -0,0 +1,96 @@
+package adapter
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+var (
+	ErrNoTransaction = errors.New("no transaction in progress")
+)
+
+// executor is implemented by both the connection pool and transactions
+type executor interface {
+	Prepare(query string) (*sql.Stmt, error)
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// writer returns the open transaction if there is one, the connection pool otherwise
+func (p *PostgreSQLAdapter) writer() executor {
+	if p.tx != nil {
+		return p.tx
+	}
+	return p.db
+}
+
+func (p *PostgreSQLAdapter) InTransaction() bool {
+	return p.tx != nil
+}
+
+// Begin starts a transaction, rows are written in it until it is committed or rolled back
+func (p *PostgreSQLAdapter) Begin() error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	slog.Debug("transaction begun")
+	p.tx = tx
+	return nil
+}
+
+func (p *PostgreSQLAdapter) Commit() error {
+	if p.tx == nil {
+		return ErrNoTransaction
+	}
+
+	err := p.tx.Commit()
+	p.tx = nil
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	slog.Debug("transaction committed")
+	return nil
+}
+
+// Rollback rolls back the open transaction, there being none is not an error
+func (p *PostgreSQLAdapter) Rollback() error {
+	if p.tx == nil {
+		return nil
+	}
+
+	err := p.tx.Rollback()
+	p.tx = nil
+	if err != nil {
+		return fmt.Errorf("failed to roll back transaction: %w", err)
+	}
+	slog.Debug("transaction rolled back")
+	return nil
+}
+
+func (p *PostgreSQLAdapter) Savepoint(name string) error {
+	return p.execInTx(fmt.Sprintf("savepoint %s;", quoteIdent(name)))
+}
+
+func (p *PostgreSQLAdapter) ReleaseSavepoint(name string) error {
+	return p.execInTx(fmt.Sprintf("release savepoint %s;", quoteIdent(name)))
+}
+
+// RollbackToSavepoint undoes the writes since the savepoint, the transaction can be carried on afterwards
+func (p *PostgreSQLAdapter) RollbackToSavepoint(name string) error {
+	return p.execInTx(fmt.Sprintf("rollback to savepoint %s;", quoteIdent(name)))
+}
+
+func (p *PostgreSQLAdapter) execInTx(query string) error {
+	if p.tx == nil {
+		return ErrNoTransaction
+	}
+
+	slog.Debug("executing in transaction", "query", query)
+	if _, err := p.tx.Exec(query); err != nil {
+		return fmt.Errorf("failed to execute '%s': %w", query, err)
+	}
+	return nil
+}
@@ -1,13 +1,116 @@
 package config
 
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// TxMode is the scope of the transactions generated rows are written in
+type TxMode string
+
+const (
+	TxNone  TxMode = "none"
+	TxRun   TxMode = "run"
+	TxTable TxMode = "table"
+	TxBatch TxMode = "batch"
+)
+
 type Config struct {
-	Host     string
-	Port     uint
-	Database string
-	Username string
-	Password string
-	SSLMode  string
-	Tables   []string
+	Connection
+	// database the data is written into by generate (optional), mask and subset,
+	// empty settings are inherited from the source connection
+	Target Connection
+	Tables []string
+	// recipe file or directory, defaults to ./<database>.recipe.json
+	RecipePath string
+	// recipe of user overrides (annotations, row counts, ...) applied to the baked tables
+	OverlayPath string
+	// write the baked recipe to the recipe path
+	SaveRecipe bool
+	// merge introspected tables into the existing recipe instead of overwriting it
+	Merge bool
+	// keep the exact profiled values (min/max, histogram bounds, most common values) instead of rounding them
+	ExactStats bool
+	// rewrite YAML and TOML recipe files holding comments, which are lost
+	Force    bool
 	DataSize uint32
 	IterFrom uint32
+	// scope of the transactions generated rows are written in, rows between savepoints of a transaction
+	Transaction TxMode
+	BatchSize   uint32
+	// write everything in a single transaction which is always rolled back
+	DryRun bool
+	// file the progress of the run is recorded in to resume it after a failure
+	StatePath string
+	// start from the iteration following the highest unique values in the database
+	AutoIter bool
+	// remove the data of previous runs first, either all rows of the tables or the generated ones only
+	// (by the tag column or the unique values of the iteration range)
+	Truncate        bool
+	DeleteGenerated bool
+	// value written into the tag columns of the tables
+	Tag string
+	// no progress and messages (warnings and errors only), or debug logs including every written query
+	Quiet   bool
+	Verbose bool
+	// file the JSON summary of the run is written into, "-" for stdout
+	SummaryPath string
+	// default probability of generating NULL for nullable columns
+	NullRatio float64
+	// scramble the order of unique values (a permutation keyed by the seed) so that they aren't sequential
+	Scramble bool
+	Seed     uint64
+	// secret key of the deterministic (HMAC based) masking
+	MaskKey string
+	// apply the column masks to copied rows (subset)
+	Mask bool
+	// subset root table (schema.table) and its rows to start from, either a sample percentage
+	// or a query selecting a key column of the root table
+	SubsetRoot    string
+	SubsetPercent float64
+	SubsetQuery   string
+}
+
+// TargetConfig returns the config with the target connection in place of the source one,
+// a target given by a DSN stands on its own, otherwise empty target settings are taken from the source
+func (c Config) TargetConfig() Config {
+	target := c.Target
+	if target.DSN == "" {
+		target = target.withDefaults(c.Connection)
+	}
+
+	config := c
+	config.Connection = target
+	return config
+}
+
+// ApplyProfile fills the settings which were not given explicitly from the profile, a connection given
+// by a DSN (flag or environment) stands on its own and takes none of the profile connection settings
+func (c *Config) ApplyProfile(profile Profile) {
+	if c.DSN == "" {
+		c.Connection = c.Connection.withDefaults(profile.Connection)
+	}
+	if c.Target.DSN == "" {
+		c.Target = c.Target.withDefaults(profile.Target)
+	}
+	if c.RecipePath == "" {
+		c.RecipePath = profile.Recipe
+	}
+}
+
+// RecipeFilePath returns the recipe path given by the user or the default one of the database,
+// a database which is not given explicitly is resolved from the DSN and the environment
+func (c Config) RecipeFilePath() string {
+	if c.RecipePath != "" {
+		return c.RecipePath
+	}
+
+	database := c.Database
+	if database == "" {
+		if connConfig, err := pgconn.ParseConfig(c.ConnectionString()); err == nil {
+			database = connConfig.Database
+		}
+	}
+	return fmt.Sprintf("./%s.recipe.json", database)
 }
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConnectionString(t *testing.T) {
+	testCases := []struct {
+		name       string
+		connection Connection
+		want       string
+	}{
+		{
+			name:       "nothing given",
+			connection: Connection{},
+			want:       "",
+		},
+		{
+			name:       "settings",
+			connection: Connection{Host: "localhost", Port: 5433, Database: "shop", Username: "baker", SSLMode: "verify-full", SSLRootCert: "/certs/ca.pem"},
+			want:       "host=localhost port=5433 dbname=shop user=baker sslmode=verify-full sslrootcert=/certs/ca.pem",
+		},
+		{
+			name:       "quoted values",
+			connection: Connection{Password: `it's a secret\`},
+			want:       `password='it\'s a secret\\'`,
+		},
+		{
+			name:       "keyword/value dsn",
+			connection: Connection{DSN: "host=db dbname=shop", Database: "shop_copy"},
+			want:       "host=db dbname=shop dbname=shop_copy",
+		},
+		{
+			name:       "url dsn",
+			connection: Connection{DSN: "postgres://baker@db:5432/shop?sslmode=require", Database: "shop_copy", SSLMode: "verify-full"},
+			want:       "postgres://baker@db:5432/shop?dbname=shop_copy&sslmode=verify-full",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.connection.ConnectionString(); got != tc.want {
+				t.Errorf("ConnectionString() = %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTargetConfig(t *testing.T) {
+	config := Config{
+		Connection: Connection{Host: "prod", Port: 5432, Database: "shop", Username: "baker", SSLMode: "require"},
+		Target:     Connection{Database: "shop_copy"},
+	}
+
+	target := config.TargetConfig()
+	want := Connection{Host: "prod", Port: 5432, Database: "shop_copy", Username: "baker", SSLMode: "require"}
+	if target.Connection != want {
+		t.Errorf("TargetConfig() = %+v; want %+v", target.Connection, want)
+	}
+
+	config.Target = Connection{DSN: "postgres://staging/shop"}
+	target = config.TargetConfig()
+	if target.Connection != config.Target {
+		t.Errorf("TargetConfig() = %+v; want the target DSN only", target.Connection)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "dbaker.yaml")
+	contents := `
+default: local
+profiles:
+  local:
+    host: localhost
+    database: shop
+    username: baker
+    sslmode: disable
+  staging:
+    dsn: postgres://staging.internal/shop
+    sslrootcert: /certs/ca.pem
+    recipe: ./recipes/
+    target:
+      database: shop_copy
+`
+	if err := os.WriteFile(filePath, []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	file, err := ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("ReadFile() unexpected error: %v", err)
+	}
+
+	profile, err := file.Profile("")
+	if err != nil {
+		t.Fatalf("Profile() unexpected error: %v", err)
+	}
+	config := Config{Connection: Connection{Database: "other"}}
+	config.ApplyProfile(profile)
+	want := Connection{Host: "localhost", Database: "other", Username: "baker", SSLMode: "disable"}
+	if config.Connection != want {
+		t.Errorf("ApplyProfile() = %+v; want %+v", config.Connection, want)
+	}
+
+	profile, err = file.Profile("staging")
+	if err != nil {
+		t.Fatalf("Profile() unexpected error: %v", err)
+	}
+	config = Config{}
+	config.ApplyProfile(profile)
+	if config.DSN != "postgres://staging.internal/shop" || config.SSLRootCert != "/certs/ca.pem" || config.Target.Database != "shop_copy" || config.RecipePath != "./recipes/" {
+		t.Errorf("ApplyProfile() = %+v; want the staging settings", config)
+	}
+
+	// a DSN given explicitly takes none of the profile connection settings
+	profile, _ = file.Profile("")
+	config = Config{Connection: Connection{DSN: "postgres://u@prod.example/shop"}}
+	config.ApplyProfile(profile)
+	if want := (Connection{DSN: "postgres://u@prod.example/shop"}); config.Connection != want {
+		t.Errorf("ApplyProfile() = %+v; want %+v", config.Connection, want)
+	}
+	if connectionString := config.ConnectionString(); connectionString != "postgres://u@prod.example/shop" {
+		t.Errorf("ConnectionString() = %q; want the DSN alone", connectionString)
+	}
+
+	if _, err := file.Profile("prod"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("Profile() error = %v; want %v", err, ErrProfileNotFound)
+	}
+}
+
+func TestReadFileRejectsUnknownSettings(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "dbaker.yaml")
+	if err := os.WriteFile(filePath, []byte("profiles:\n  local:\n    hots: localhost\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	if _, err := ReadFile(filePath); err == nil {
+		t.Errorf("ReadFile() expected an error for the unknown setting")
+	}
+}
+
+func TestRecipeFilePath(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{name: "explicit path", config: Config{RecipePath: "recipes/", Connection: Connection{Database: "shop"}}, want: "recipes/"},
+		{name: "database", config: Config{Connection: Connection{Database: "shop"}}, want: "./shop.recipe.json"},
+		{name: "database of the dsn", config: Config{Connection: Connection{DSN: "postgres://db/warehouse"}}, want: "./warehouse.recipe.json"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.config.RecipeFilePath(); got != tc.want {
+				t.Errorf("RecipeFilePath() = %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
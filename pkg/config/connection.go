@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Connection holds the settings of a single database connection. Settings left empty are taken
+// from the DSN, then by pgx from the PG* environment variables, the service file and ~/.pgpass.
+type Connection struct {
+	// connection string, either a postgres:// URL or keyword/value settings
+	DSN      string `yaml:"dsn"`
+	Service  string `yaml:"service"`
+	Host     string `yaml:"host"`
+	Port     uint   `yaml:"port"`
+	Database string `yaml:"database"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	SSLMode  string `yaml:"sslmode"`
+	// certificate files of verify-ca/verify-full modes and client certificate authentication
+	SSLRootCert string `yaml:"sslrootcert"`
+	SSLCert     string `yaml:"sslcert"`
+	SSLKey      string `yaml:"sslkey"`
+}
+
+// ConnectionString returns the DSN with the explicitly given settings on top of it
+func (c Connection) ConnectionString() string {
+	settings := c.settings()
+
+	if strings.HasPrefix(c.DSN, "postgres://") || strings.HasPrefix(c.DSN, "postgresql://") {
+		dsn, err := url.Parse(c.DSN)
+		if err != nil {
+			// left to pgx to report
+			return c.DSN
+		}
+
+		// query parameters take precedence over the rest of the URL
+		query := dsn.Query()
+		for _, setting := range settings {
+			query.Set(setting[0], setting[1])
+		}
+		dsn.RawQuery = query.Encode()
+		return dsn.String()
+	}
+
+	// later keyword/value settings take precedence over earlier ones
+	var parts []string
+	if c.DSN != "" {
+		parts = append(parts, c.DSN)
+	}
+	for _, setting := range settings {
+		parts = append(parts, setting[0]+"="+quote(setting[1]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// settings returns the keyword/value pairs of the given settings
+func (c Connection) settings() [][2]string {
+	var port string
+	if c.Port != 0 {
+		port = fmt.Sprint(c.Port)
+	}
+
+	var settings [][2]string
+	for _, setting := range [][2]string{
+		{"service", c.Service},
+		{"host", c.Host},
+		{"port", port},
+		{"dbname", c.Database},
+		{"user", c.Username},
+		{"password", c.Password},
+		{"sslmode", c.SSLMode},
+		{"sslrootcert", c.SSLRootCert},
+		{"sslcert", c.SSLCert},
+		{"sslkey", c.SSLKey},
+	} {
+		if setting[1] != "" {
+			settings = append(settings, setting)
+		}
+	}
+	return settings
+}
+
+// withDefaults fills the empty settings from the defaults
+func (c Connection) withDefaults(defaults Connection) Connection {
+	if c.DSN == "" {
+		c.DSN = defaults.DSN
+	}
+	if c.Service == "" {
+		c.Service = defaults.Service
+	}
+	if c.Host == "" {
+		c.Host = defaults.Host
+	}
+	if c.Port == 0 {
+		c.Port = defaults.Port
+	}
+	if c.Database == "" {
+		c.Database = defaults.Database
+	}
+	if c.Username == "" {
+		c.Username = defaults.Username
+	}
+	if c.Password == "" {
+		c.Password = defaults.Password
+	}
+	if c.SSLMode == "" {
+		c.SSLMode = defaults.SSLMode
+	}
+	if c.SSLRootCert == "" {
+		c.SSLRootCert = defaults.SSLRootCert
+	}
+	if c.SSLCert == "" {
+		c.SSLCert = defaults.SSLCert
+	}
+	if c.SSLKey == "" {
+		c.SSLKey = defaults.SSLKey
+	}
+	return c
+}
+
+// quote quotes the keyword/value setting value when needed
+func quote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " '\\\t\n") {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+)
+
+// DefaultFilePath is the config file read when no other one is given
+const DefaultFilePath = "./dbaker.yaml"
+
+// File is the dbaker config file holding the settings of environments as named profiles
+type File struct {
+	// profile used when none is selected
+	Default  string             `yaml:"default"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile holds the settings of an environment, explicitly given flags take precedence
+type Profile struct {
+	Connection `yaml:",inline"`
+	Target     Connection `yaml:"target"`
+	Recipe     string     `yaml:"recipe"`
+}
+
+// ReadFile reads the config file, unknown settings are errors to catch typos
+func ReadFile(filePath string) (*File, error) {
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var file File
+	decoder := yaml.NewDecoder(bytes.NewReader(contents))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", filePath, err)
+	}
+
+	return &file, nil
+}
+
+// Profile returns the named profile, or the default one when the name is empty
+func (f File) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = f.Default
+	}
+	if name == "" {
+		return Profile{}, nil
+	}
+
+	profile, ok := f.Profiles[name]
+	if !ok {
+		var names []string
+		for name := range f.Profiles {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return Profile{}, fmt.Errorf("%w: %s, expected one of %s", ErrProfileNotFound, name, strings.Join(names, ", "))
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,254 @@
+package generator
+
+import (
+	"dbaker/pkg/model"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+var (
+	ErrUnknownAnnotation = errors.New("unknown annotation")
+	ErrInvalidAnnotation = errors.New("invalid annotation")
+)
+
+// annotationFunc generates a single value of an annotated column
+type annotationFunc func() (any, error)
+
+// compileAnnotation resolves the annotation expression into a value generating function.
+// An annotation is either a gofakeit function name (`email`) optionally followed by its
+// parameters in declaration order (`intRange(1,100)`, `regex([A-Z]{3}-\d{4})`) or one of
+// the built-in functions:
+//   - oneOf(a,b,c) picks one of the listed values
+//   - template({{firstName}}.{{lastName}}@corp.com) replaces {{annotation}} placeholders
+func compileAnnotation(annotation string) (annotationFunc, error) {
+	name, args, hasArgs, err := splitAnnotation(annotation)
+	if err != nil {
+		return nil, err
+	}
+
+	var fn annotationFunc
+	switch strings.ToLower(name) {
+	case "oneof":
+		fn, err = compileOneOf(args)
+	case "template":
+		fn, err = compileTemplate(args)
+	default:
+		fn, err = compileFakeitFunc(name, args, hasArgs)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// generate a value to surface invalid parameters before any row is written
+	if _, err := fn(); err != nil {
+		return nil, fmt.Errorf("%w '%s': %w", ErrInvalidAnnotation, annotation, err)
+	}
+
+	return fn, nil
+}
+
+// splitAnnotation splits `name(args)` into the function name and its raw arguments
+func splitAnnotation(annotation string) (name string, args string, hasArgs bool, err error) {
+	annotation = strings.TrimSpace(annotation)
+	open := strings.Index(annotation, "(")
+	if open < 0 {
+		return annotation, "", false, nil
+	}
+
+	if !strings.HasSuffix(annotation, ")") {
+		return "", "", false, fmt.Errorf("%w '%s': missing closing parenthesis", ErrInvalidAnnotation, annotation)
+	}
+
+	name = strings.TrimSpace(annotation[:open])
+	if name == "" {
+		return "", "", false, fmt.Errorf("%w '%s': missing function name", ErrInvalidAnnotation, annotation)
+	}
+
+	return name, annotation[open+1 : len(annotation)-1], true, nil
+}
+
+// splitArgs splits arguments on top level commas, commas inside quotes or brackets are kept
+func splitArgs(args string) []string {
+	var result []string
+	depth := 0
+	var quote rune
+	start := 0
+	for index, r := range args {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(' || r == '[' || r == '{':
+			depth++
+		case r == ')' || r == ']' || r == '}':
+			depth--
+		case r == ',' && depth == 0:
+			result = append(result, unquote(args[start:index]))
+			start = index + 1
+		}
+	}
+
+	return append(result, unquote(args[start:]))
+}
+
+func unquote(arg string) string {
+	arg = strings.TrimSpace(arg)
+	if len(arg) >= 2 && (arg[0] == '\'' || arg[0] == '"') && arg[len(arg)-1] == arg[0] {
+		return arg[1 : len(arg)-1]
+	}
+
+	return arg
+}
+
+func compileOneOf(args string) (annotationFunc, error) {
+	values := splitArgs(args)
+	if strings.TrimSpace(args) == "" {
+		return nil, fmt.Errorf("%w 'oneOf': at least one value is required", ErrInvalidAnnotation)
+	}
+
+	return func() (any, error) {
+		return values[gofakeit.IntN(len(values))], nil
+	}, nil
+}
+
+func compileTemplate(args string) (annotationFunc, error) {
+	var parts []annotationFunc
+	rest := args
+	for rest != "" {
+		open := strings.Index(rest, "{{")
+		if open < 0 {
+			parts = append(parts, literal(rest))
+			break
+		}
+
+		close := strings.Index(rest[open:], "}}")
+		if close < 0 {
+			return nil, fmt.Errorf("%w 'template': unterminated placeholder in '%s'", ErrInvalidAnnotation, args)
+		}
+
+		if open > 0 {
+			parts = append(parts, literal(rest[:open]))
+		}
+
+		fn, err := compileAnnotation(rest[open+2 : open+close])
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, fn)
+		rest = rest[open+close+2:]
+	}
+
+	return func() (any, error) {
+		builder := strings.Builder{}
+		for _, part := range parts {
+			value, err := part()
+			if err != nil {
+				return nil, err
+			}
+			builder.WriteString(fmt.Sprint(value))
+		}
+		return builder.String(), nil
+	}, nil
+}
+
+func literal(value string) annotationFunc {
+	return func() (any, error) {
+		return value, nil
+	}
+}
+
+func compileFakeitFunc(name string, args string, hasArgs bool) (annotationFunc, error) {
+	info := gofakeit.GetFuncLookup(strings.ToLower(name))
+	if info == nil {
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownAnnotation, name)
+	}
+
+	var values []string
+	switch {
+	case !hasArgs || strings.TrimSpace(args) == "":
+	case len(info.Params) == 1:
+		// a single parameter gets the raw arguments, regular expressions contain commas
+		values = []string{args}
+	default:
+		values = splitArgs(args)
+	}
+
+	if len(values) > len(info.Params) {
+		return nil, fmt.Errorf("%w '%s': expected at most %d parameters, got %d", ErrInvalidAnnotation, name, len(info.Params), len(values))
+	}
+
+	params := gofakeit.NewMapParams()
+	for index, value := range values {
+		params.Add(info.Params[index].Field, value)
+	}
+
+	return func() (any, error) {
+		return info.Generate(gofakeit.GlobalFaker, params, info)
+	}, nil
+}
+
+// coerceVal converts an annotation value to a value the column type accepts
+func coerceVal(col model.Column, value any) (any, error) {
+	switch {
+	case isText(col.Typ) || col.Typ == model.UUID:
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = v
+		case time.Time:
+			text = v.Format(time.RFC3339)
+		default:
+			text = fmt.Sprint(v)
+		}
+		if col.MaxLength > 0 && uint(utf8.RuneCountInString(text)) > col.MaxLength {
+			text = string([]rune(text)[:col.MaxLength])
+		}
+		return text, nil
+
+	case col.Typ == model.SmallInt || col.Typ == model.Int || col.Typ == model.BigInt:
+		if text, ok := value.(string); ok {
+			return strconv.ParseInt(text, 10, 64)
+		}
+		ordinal, err := toOrdinal(col.Typ, value)
+		if err != nil {
+			return nil, err
+		}
+		return int64(math.Round(ordinal)), nil
+
+	case isFloat(col.Typ):
+		if text, ok := value.(string); ok {
+			return strconv.ParseFloat(text, 64)
+		}
+		return toOrdinal(col.Typ, value)
+
+	case col.Typ == model.Boolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+
+	case isTemporal(col.Typ):
+		switch v := value.(type) {
+		case time.Time:
+			return formatTemporal(col.Typ, v), nil
+		case string:
+			if _, err := parseTemporal(col.Typ, v); err == nil {
+				return v, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("value '%v' (%T) can't be stored in '%s' column", value, value, col.Typ)
+}
@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"dbaker/pkg/config"
+	"dbaker/pkg/model"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCompileAnnotation(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		pattern    string
+		wantErr    error
+	}{
+		{name: "function name", annotation: "email", pattern: `^\S+@\S+$`},
+		{name: "case insensitive function name", annotation: "firstName", pattern: `^\S+$`},
+		{name: "parameterized function", annotation: "intRange(1,100)", pattern: `^([1-9][0-9]?|100)$`},
+		{name: "regex with commas", annotation: `regex([A-Z]{3,3}-\d{4})`, pattern: `^[A-Z]{3}-\d{4}$`},
+		{name: "one of", annotation: "oneOf(a, b,'c,d')", pattern: `^(a|b|c,d)$`},
+		{name: "template", annotation: "template({{firstName}}.{{lastName}}@corp.com)", pattern: `^\S+\.\S+@corp\.com$`},
+		{name: "template with parameters", annotation: "template(ID-{{intRange(1,9)}})", pattern: `^ID-[1-9]$`},
+		{name: "unknown function", annotation: "nonsense", wantErr: ErrUnknownAnnotation},
+		{name: "unknown function in template", annotation: "template({{nonsense}})", wantErr: ErrUnknownAnnotation},
+		{name: "invalid parameter", annotation: "intRange(a,b)", wantErr: ErrInvalidAnnotation},
+		{name: "too many parameters", annotation: "intRange(1,2,3)", wantErr: ErrInvalidAnnotation},
+		{name: "missing parenthesis", annotation: "intRange(1,2", wantErr: ErrInvalidAnnotation},
+		{name: "empty one of", annotation: "oneOf()", wantErr: ErrInvalidAnnotation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := compileAnnotation(tt.annotation)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("compileAnnotation(%q) error = %v; want %v", tt.annotation, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("compileAnnotation(%q) unexpected error: %v", tt.annotation, err)
+			}
+
+			value, err := fn()
+			if err != nil {
+				t.Fatalf("compileAnnotation(%q)() unexpected error: %v", tt.annotation, err)
+			}
+			coerced, err := coerceVal(model.Column{Typ: model.Text}, value)
+			if err != nil {
+				t.Fatalf("coerceVal(%v) unexpected error: %v", value, err)
+			}
+			if !regexp.MustCompile(tt.pattern).MatchString(coerced.(string)) {
+				t.Errorf("compileAnnotation(%q)() = %q; want match of %s", tt.annotation, coerced, tt.pattern)
+			}
+		})
+	}
+}
+
+func TestPrepareReportsAllAnnotations(t *testing.T) {
+	tables := []model.Table{
+		{
+			Name:   "users",
+			Schema: "public",
+			Columns: []model.Column{
+				{Name: "email", Typ: model.Varchar, Annotation: "email"},
+				{Name: "first_name", Typ: model.Varchar, Annotation: "frstName"},
+				{Name: "age", Typ: model.Int, Annotation: "intRange(x,y)"},
+			},
+		},
+	}
+
+	err := NewValueGenerator(config.Config{}).Prepare(tables)
+	if err == nil {
+		t.Fatal("Prepare() expected an error")
+	}
+
+	for _, expected := range []string{"column 'first_name'", "column 'age'"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Prepare() error = %q; want it to mention %s", err, expected)
+		}
+	}
+	if strings.Contains(err.Error(), "column 'email'") {
+		t.Errorf("Prepare() error = %q; valid annotation reported", err)
+	}
+}
+
+func TestGenAnnotatedValCoercesToColumn(t *testing.T) {
+	gen := NewValueGenerator(config.Config{})
+
+	value, err := gen.GenAnnotatedVal(model.Column{Name: "code", Typ: model.Varchar, MaxLength: 3, Annotation: "regex([A-Z]{10})"})
+	if err != nil {
+		t.Fatalf("GenAnnotatedVal() unexpected error: %v", err)
+	}
+	if len(value.(string)) != 3 {
+		t.Errorf("GenAnnotatedVal() = %q; want value truncated to 3 characters", value)
+	}
+
+	value, err = gen.GenAnnotatedVal(model.Column{Name: "age", Typ: model.Int, Annotation: "intRange(1,100)"})
+	if err != nil {
+		t.Fatalf("GenAnnotatedVal() unexpected error: %v", err)
+	}
+	if _, ok := value.(int64); !ok {
+		t.Errorf("GenAnnotatedVal() = %T; want int64", value)
+	}
+}
@@ -0,0 +1,553 @@
+package generator
+
+import (
+	"dbaker/pkg/model"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+var (
+	ErrConstraintNotSatisfiable = errors.New("column constraints can't be satisfied")
+)
+
+// maximum number of attempts to generate a value which is not explicitly excluded (<>)
+const maxExclusionRetries = 10
+
+// bounds is the closed interval of ordinals a constrained value has to fall in
+type bounds struct {
+	low, high float64
+	hasLow    bool
+	hasHigh   bool
+	relative  bool // a bound comes from another column of the row
+}
+
+func (b *bounds) raiseLow(value float64) {
+	if !b.hasLow || value > b.low {
+		b.low = value
+		b.hasLow = true
+	}
+}
+
+func (b *bounds) lowerHigh(value float64) {
+	if !b.hasHigh || value < b.high {
+		b.high = value
+		b.hasHigh = true
+	}
+}
+
+// columnConstraints are the check constraints of a column resolved against the row
+type columnConstraints struct {
+	// values listed by IN or = constraints, if any
+	allowed    []string
+	hasAllowed bool
+	excluded   []string
+	// value of another column the value equals (a = b)
+	equal                     any
+	hasEqual                  bool
+	valueBounds, lengthBounds bounds
+}
+
+// resolveConstraints resolves the column check constraints, row contains values of the columns
+// generated so far (referenced by cross column constraints)
+func resolveConstraints(col model.Column, row map[string]any) (columnConstraints, error) {
+	var c columnConstraints
+	for _, constraint := range col.Constraints {
+		switch {
+		case constraint.Op == model.OpIn || (constraint.Op == model.OpEq && constraint.Column == "" && !constraint.OnLength):
+			values := constraint.Values
+			if constraint.Op == model.OpEq {
+				values = []string{constraint.Value}
+			}
+			if c.hasAllowed {
+				c.allowed = intersect(c.allowed, values)
+			} else {
+				c.allowed = values
+				c.hasAllowed = true
+			}
+
+		case constraint.Op == model.OpNe && constraint.Column == "" && !constraint.OnLength:
+			c.excluded = append(c.excluded, constraint.Value)
+
+		case constraint.OnLength:
+			length, err := strconv.ParseFloat(constraint.Value, 64)
+			if err != nil {
+				return c, fmt.Errorf("%w: invalid length '%s'", ErrConstraintNotSatisfiable, constraint.Value)
+			}
+			if err := narrow(&c.lengthBounds, constraint.Op, length, 1, false); err != nil {
+				return c, err
+			}
+
+		case constraint.Column != "":
+			other, ok := row[constraint.Column]
+			if !ok || other == nil {
+				// the referenced column is generated by the database or null, nothing to compare with
+				continue
+			}
+			if constraint.Op == model.OpEq {
+				c.equal, c.hasEqual = other, true
+				continue
+			}
+			if constraint.Op == model.OpNe {
+				c.excluded = append(c.excluded, fmt.Sprint(other))
+				continue
+			}
+			ordinal, err := toOrdinal(col.Typ, other)
+			if err != nil {
+				return c, err
+			}
+			if err := narrow(&c.valueBounds, constraint.Op, ordinal, ordinalStep(col.Typ), true); err != nil {
+				return c, err
+			}
+
+		default:
+			ordinal, err := toOrdinal(col.Typ, constraint.Value)
+			if err != nil {
+				return c, err
+			}
+			if err := narrow(&c.valueBounds, constraint.Op, ordinal, ordinalStep(col.Typ), false); err != nil {
+				return c, err
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// GenConstrainedVal generates a value satisfying the column check constraints,
+// row contains values of the columns generated so far (referenced by cross column constraints)
+func (g *ValueGenerator) GenConstrainedVal(col model.Column, row map[string]any) (any, error) {
+	c, err := resolveConstraints(col, row)
+	if err != nil {
+		return nil, err
+	}
+	if c.hasEqual {
+		return c.equal, nil
+	}
+
+	if c.hasAllowed {
+		allowed := slices.DeleteFunc(slices.Clone(c.allowed), func(value string) bool {
+			return slices.Contains(c.excluded, value)
+		})
+		if len(allowed) == 0 {
+			return nil, fmt.Errorf("%w: no value left to choose from", ErrConstraintNotSatisfiable)
+		}
+		return parseLiteral(col.Typ, allowed[gofakeit.IntN(len(allowed))])
+	}
+
+	for range maxExclusionRetries {
+		value, err := g.genBoundedVal(col, c.valueBounds, c.lengthBounds)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(c.excluded, fmt.Sprint(value)) {
+			return value, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: failed to generate a value which is not excluded", ErrConstraintNotSatisfiable)
+}
+
+// constrainVal makes a value drawn without regard to the column check constraints (e.g. from a distribution)
+// satisfy them: values out of range are clamped to the closest bound, values which are not allowed otherwise
+// are replaced by a constrained value
+func (g *ValueGenerator) constrainVal(col model.Column, value any, row map[string]any) (any, error) {
+	c, err := resolveConstraints(col, row)
+	if err != nil {
+		return nil, err
+	}
+	if c.hasEqual {
+		return c.equal, nil
+	}
+
+	text := fmt.Sprint(value)
+	lengthFits := !c.lengthBounds.hasLow && !c.lengthBounds.hasHigh
+	if length := float64(len([]rune(text))); !lengthFits {
+		lengthFits = (!c.lengthBounds.hasLow || length >= c.lengthBounds.low) && (!c.lengthBounds.hasHigh || length <= c.lengthBounds.high)
+	}
+	if (c.hasAllowed && !slices.Contains(c.allowed, text)) || !lengthFits {
+		return g.GenConstrainedVal(col, row)
+	}
+
+	if c.valueBounds.hasLow || c.valueBounds.hasHigh {
+		ordinal, err := toOrdinal(col.Typ, value)
+		if err != nil {
+			return nil, err
+		}
+		if c.valueBounds.hasLow && c.valueBounds.hasHigh && c.valueBounds.low > c.valueBounds.high {
+			return nil, fmt.Errorf("%w: empty range [%v, %v]", ErrConstraintNotSatisfiable, c.valueBounds.low, c.valueBounds.high)
+		}
+		if c.valueBounds.hasLow && ordinal < c.valueBounds.low {
+			value = fromOrdinal(col.Typ, c.valueBounds.low)
+		}
+		if c.valueBounds.hasHigh && ordinal > c.valueBounds.high {
+			value = fromOrdinal(col.Typ, c.valueBounds.high)
+		}
+	}
+
+	if slices.Contains(c.excluded, fmt.Sprint(value)) {
+		return g.GenConstrainedVal(col, row)
+	}
+	return value, nil
+}
+
+func (g *ValueGenerator) genBoundedVal(col model.Column, valueBounds bounds, lengthBounds bounds) (any, error) {
+	if lengthBounds.hasLow || lengthBounds.hasHigh {
+		return genBoundedText(col, lengthBounds)
+	}
+
+	if !valueBounds.hasLow && !valueBounds.hasHigh {
+		return g.GenRawVal(col)
+	}
+
+	low, high, ok := typeRange(col.Typ)
+	if !ok {
+		return nil, fmt.Errorf("%w: range constraint on '%s' column", ErrConstraintNotSatisfiable, col.Typ)
+	}
+
+	// floats and temporal values have no natural limits, stay close to the one known bound,
+	// the same applies to bounds relative to another column (e.g. end_at > start_at)
+	spanned := isFloat(col.Typ) || isTemporal(col.Typ) || valueBounds.relative
+	typeLow, typeHigh := low, high
+	if valueBounds.hasLow {
+		low = valueBounds.low
+		if !valueBounds.hasHigh && spanned {
+			high = low + defaultSpan(col.Typ)
+		}
+	}
+	if valueBounds.hasHigh {
+		high = valueBounds.high
+		if !valueBounds.hasLow && spanned {
+			low = high - defaultSpan(col.Typ)
+		}
+	}
+	if !isTemporal(col.Typ) {
+		low, high = math.Max(low, typeLow), math.Min(high, typeHigh)
+	}
+
+	if low > high {
+		return nil, fmt.Errorf("%w: empty range [%v, %v]", ErrConstraintNotSatisfiable, low, high)
+	}
+
+	if isFloat(col.Typ) {
+		return fromOrdinal(col.Typ, gofakeit.Float64Range(low, high)), nil
+	}
+
+	return fromOrdinal(col.Typ, float64(gofakeit.IntRange(toInt(math.Ceil(low)), toInt(math.Floor(high))))), nil
+}
+
+func genBoundedText(col model.Column, lengthBounds bounds) (any, error) {
+	if !isText(col.Typ) {
+		return nil, fmt.Errorf("%w: length constraint on '%s' column", ErrConstraintNotSatisfiable, col.Typ)
+	}
+
+	if col.MaxLength > 0 {
+		lengthBounds.lowerHigh(float64(col.MaxLength))
+	}
+	if !lengthBounds.hasLow {
+		lengthBounds.raiseLow(0)
+	}
+	if !lengthBounds.hasHigh {
+		lengthBounds.lowerHigh(lengthBounds.low + defaultSpan(col.Typ))
+	}
+
+	low, high := int(math.Ceil(lengthBounds.low)), int(math.Floor(lengthBounds.high))
+	if low > high {
+		return nil, fmt.Errorf("%w: empty length range [%d, %d]", ErrConstraintNotSatisfiable, low, high)
+	}
+
+	return gofakeit.LetterN(uint(gofakeit.IntRange(low, high))), nil
+}
+
+// narrow applies a comparison to the bounds, step is the smallest difference between two values
+func narrow(b *bounds, op model.ConstraintOp, value float64, step float64, relative bool) error {
+	switch op {
+	case model.OpGt:
+		b.raiseLow(nextOrdinal(value, step, 1))
+	case model.OpGte:
+		b.raiseLow(value)
+	case model.OpLt:
+		b.lowerHigh(nextOrdinal(value, step, -1))
+	case model.OpLte:
+		b.lowerHigh(value)
+	case model.OpEq:
+		b.raiseLow(value)
+		b.lowerHigh(value)
+	default:
+		return fmt.Errorf("%w: unsupported operator '%s'", ErrConstraintNotSatisfiable, op)
+	}
+
+	if relative {
+		b.relative = true
+	}
+
+	return nil
+}
+
+func nextOrdinal(value float64, step float64, direction float64) float64 {
+	if step == 0 {
+		return math.Nextafter(value, direction*math.Inf(1))
+	}
+
+	if direction > 0 {
+		return math.Floor(value/step)*step + step
+	}
+	return math.Ceil(value/step)*step - step
+}
+
+// toInt converts the ordinal to int, clamping values outside of the int range
+func toInt(value float64) int {
+	if value >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	if value <= math.MinInt64 {
+		return math.MinInt64
+	}
+	return int(value)
+}
+
+func intersect(a []string, b []string) []string {
+	var result []string
+	for _, value := range a {
+		if slices.Contains(b, value) {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
+
+func isFloat(typ model.ColumnType) bool {
+	return typ == model.Real || typ == model.Double
+}
+
+func isText(typ model.ColumnType) bool {
+	return typ == model.Char || typ == model.Varchar || typ == model.Text
+}
+
+func isTemporal(typ model.ColumnType) bool {
+	switch typ {
+	case model.Date, model.Time, model.Timestamp, model.TimestampTZ:
+		return true
+	default:
+		return false
+	}
+}
+
+// typeRange returns the range of ordinals the column type can hold
+func typeRange(typ model.ColumnType) (float64, float64, bool) {
+	switch typ {
+	case model.SmallInt:
+		return math.MinInt16, math.MaxInt16, true
+	case model.Int:
+		return math.MinInt32, math.MaxInt32, true
+	case model.BigInt:
+		return math.MinInt64, math.MaxInt64, true
+	case model.Real:
+		return -math.MaxFloat32, math.MaxFloat32, true
+	case model.Double:
+		return -math.MaxFloat64, math.MaxFloat64, true
+	case model.Date:
+		return float64(time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC).Unix() / secondsPerDay), float64(time.Now().Unix() / secondsPerDay), true
+	case model.Time:
+		return 0, secondsPerDay - 1, true
+	case model.Timestamp, model.TimestampTZ:
+		return float64(time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC).Unix()), float64(time.Now().Unix()), true
+	default:
+		return 0, 0, false
+	}
+}
+
+// defaultSpan is the width of the generated range when only one bound is known
+func defaultSpan(typ model.ColumnType) float64 {
+	switch typ {
+	case model.Date:
+		return 30
+	case model.Time:
+		return 3600
+	case model.Timestamp, model.TimestampTZ:
+		return 30 * secondsPerDay
+	default:
+		return 100
+	}
+}
+
+func ordinalStep(typ model.ColumnType) float64 {
+	if isFloat(typ) {
+		return 0
+	}
+	return 1
+}
+
+const secondsPerDay = 24 * 60 * 60
+
+// toOrdinal maps a value to a number preserving the ordering of the column type:
+// numbers map to themselves, dates to days since epoch, times to seconds since midnight
+// and timestamps to seconds since epoch
+func toOrdinal(typ model.ColumnType, value any) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case time.Time:
+		return timeToOrdinal(typ, v), nil
+	case string:
+		if isTemporal(typ) {
+			t, err := parseTemporal(typ, v)
+			if err != nil {
+				return 0, err
+			}
+			return timeToOrdinal(typ, t), nil
+		}
+		ordinal, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: '%s' is not a number", ErrConstraintNotSatisfiable, v)
+		}
+		return ordinal, nil
+	default:
+		return 0, fmt.Errorf("%w: can't compare value '%v'", ErrConstraintNotSatisfiable, value)
+	}
+}
+
+func timeToOrdinal(typ model.ColumnType, t time.Time) float64 {
+	switch typ {
+	case model.Date:
+		return math.Floor(float64(t.Unix()) / secondsPerDay)
+	case model.Time:
+		return float64(t.Hour()*3600 + t.Minute()*60 + t.Second())
+	default:
+		return float64(t.Unix())
+	}
+}
+
+func fromOrdinal(typ model.ColumnType, ordinal float64) any {
+	switch typ {
+	case model.SmallInt, model.Int, model.BigInt:
+		return int64(ordinal)
+	case model.Real:
+		return float32(ordinal)
+	case model.Date:
+		return formatTemporal(typ, time.Unix(int64(ordinal)*secondsPerDay, 0).UTC())
+	case model.Time, model.Timestamp, model.TimestampTZ:
+		return formatTemporal(typ, time.Unix(int64(ordinal), 0).UTC())
+	default:
+		return ordinal
+	}
+}
+
+func parseTemporal(typ model.ColumnType, value string) (time.Time, error) {
+	if value == model.NowLiteral {
+		return time.Now().UTC(), nil
+	}
+
+	layouts := []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999Z07",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02",
+		"15:04:05.999999999",
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: '%s' is not a valid %s", ErrConstraintNotSatisfiable, value, typ)
+}
+
+func formatTemporal(typ model.ColumnType, t time.Time) string {
+	switch typ {
+	case model.Date:
+		return t.Format("2006-01-02")
+	case model.Time:
+		return t.Format("15:04:05")
+	default:
+		return t.Format(time.RFC3339)
+	}
+}
+
+// parseLiteral converts a constraint literal to a value of the column type
+func parseLiteral(typ model.ColumnType, value string) (any, error) {
+	switch {
+	case typ == model.SmallInt || typ == model.Int || typ == model.BigInt:
+		return strconv.ParseInt(value, 10, 64)
+	case isFloat(typ):
+		return strconv.ParseFloat(value, 64)
+	case typ == model.Boolean:
+		return strconv.ParseBool(value)
+	case isTemporal(typ) && value == model.NowLiteral:
+		return formatTemporal(typ, time.Now().UTC()), nil
+	default:
+		return value, nil
+	}
+}
+
+// uniqueLowerBound returns the smallest value allowed by the column constraints (if there is any)
+func uniqueLowerBound(col model.Column) (int64, bool) {
+	var b bounds
+	for _, constraint := range col.Constraints {
+		if constraint.Column != "" || constraint.OnLength {
+			continue
+		}
+		if constraint.Op != model.OpGt && constraint.Op != model.OpGte {
+			continue
+		}
+		value, err := strconv.ParseFloat(constraint.Value, 64)
+		if err != nil {
+			continue
+		}
+		if err := narrow(&b, constraint.Op, value, 1, false); err != nil {
+			return 0, false
+		}
+	}
+
+	return int64(b.low), b.hasLow
+}
+
+// generationOrder orders column indexes so that columns referenced by
+// cross column constraints are generated before the columns referencing them
+func generationOrder(cols []model.Column) []int {
+	order := make([]int, 0, len(cols))
+	visited := make([]bool, len(cols))
+	visiting := make([]bool, len(cols))
+
+	var visit func(index int)
+	visit = func(index int) {
+		if visited[index] || visiting[index] {
+			return
+		}
+		visiting[index] = true
+		for _, constraint := range cols[index].Constraints {
+			if constraint.Column == "" {
+				continue
+			}
+			for refIndex, ref := range cols {
+				if ref.Name == constraint.Column {
+					visit(refIndex)
+				}
+			}
+		}
+		visiting[index] = false
+		visited[index] = true
+		order = append(order, index)
+	}
+
+	for index := range cols {
+		visit(index)
+	}
+
+	return order
+}
@@ -0,0 +1,228 @@
+package generator
+
+import (
+	"dbaker/pkg/config"
+	"dbaker/pkg/model"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestGenConstrainedValBounds(t *testing.T) {
+	tests := []struct {
+		name      string
+		column    model.Column
+		low, high float64
+	}{
+		{name: "closed range", column: model.Column{Typ: model.Int, Constraints: []model.Constraint{{Op: model.OpGt, Value: "0"}, {Op: model.OpLte, Value: "10"}}}, low: 1, high: 10},
+		{name: "lower bound only", column: model.Column{Typ: model.SmallInt, Constraints: []model.Constraint{{Op: model.OpGte, Value: "32760"}}}, low: 32760, high: 32767},
+		{name: "upper bound only", column: model.Column{Typ: model.BigInt, Constraints: []model.Constraint{{Op: model.OpLt, Value: "-5"}}}, low: -1 << 63, high: -6},
+		{name: "float range", column: model.Column{Typ: model.Double, Constraints: []model.Constraint{{Op: model.OpGt, Value: "0.5"}, {Op: model.OpLt, Value: "1"}}}, low: 0.5, high: 1},
+		{name: "equality", column: model.Column{Typ: model.Int, Constraints: []model.Constraint{{Op: model.OpEq, Value: "7"}}}, low: 7, high: 7},
+		{name: "date range", column: model.Column{Typ: model.Date, Constraints: []model.Constraint{{Op: model.OpGte, Value: "2024-01-01"}, {Op: model.OpLt, Value: "2024-02-01"}}}, low: 19723, high: 19753},
+	}
+
+	gen := NewValueGenerator(config.Config{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 200 {
+				value, err := gen.GenConstrainedVal(tt.column, nil)
+				if err != nil {
+					t.Fatalf("GenConstrainedVal() unexpected error: %v", err)
+				}
+				ordinal, err := toOrdinal(tt.column.Typ, value)
+				if err != nil {
+					t.Fatalf("toOrdinal(%v) unexpected error: %v", value, err)
+				}
+				if ordinal < tt.low || ordinal > tt.high || (isFloat(tt.column.Typ) && (ordinal == tt.low || ordinal == tt.high)) {
+					t.Fatalf("GenConstrainedVal() = %v; want within [%v, %v]", value, tt.low, tt.high)
+				}
+			}
+		})
+	}
+}
+
+func TestGenConstrainedValAllowedValues(t *testing.T) {
+	column := model.Column{Typ: model.Varchar, Constraints: []model.Constraint{
+		{Op: model.OpIn, Values: []string{"active", "banned", "deleted"}},
+		{Op: model.OpIn, Values: []string{"active", "deleted", "pending"}},
+		{Op: model.OpNe, Value: "deleted"},
+	}}
+
+	gen := NewValueGenerator(config.Config{})
+	for range 50 {
+		value, err := gen.GenConstrainedVal(column, nil)
+		if err != nil {
+			t.Fatalf("GenConstrainedVal() unexpected error: %v", err)
+		}
+		if value != "active" {
+			t.Fatalf("GenConstrainedVal() = %v; want the only value left, active", value)
+		}
+	}
+
+	numbers := model.Column{Typ: model.SmallInt, Constraints: []model.Constraint{{Op: model.OpIn, Values: []string{"1", "2", "3"}}}}
+	value, err := gen.GenConstrainedVal(numbers, nil)
+	if err != nil || !slices.Contains([]any{int64(1), int64(2), int64(3)}, value) {
+		t.Errorf("GenConstrainedVal() = %v, %v; want one of 1, 2, 3", value, err)
+	}
+}
+
+func TestGenConstrainedValLength(t *testing.T) {
+	tests := []struct {
+		name      string
+		column    model.Column
+		low, high int
+	}{
+		{name: "length range", column: model.Column{Typ: model.Text, Constraints: []model.Constraint{{Op: model.OpGte, Value: "3", OnLength: true}, {Op: model.OpLte, Value: "5", OnLength: true}}}, low: 3, high: 5},
+		{name: "minimum within the column length", column: model.Column{Typ: model.Varchar, MaxLength: 8, Constraints: []model.Constraint{{Op: model.OpGt, Value: "6", OnLength: true}}}, low: 7, high: 8},
+		{name: "exact length", column: model.Column{Typ: model.Char, MaxLength: 2, Constraints: []model.Constraint{{Op: model.OpEq, Value: "2", OnLength: true}}}, low: 2, high: 2},
+	}
+
+	gen := NewValueGenerator(config.Config{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				value, err := gen.GenConstrainedVal(tt.column, nil)
+				if err != nil {
+					t.Fatalf("GenConstrainedVal() unexpected error: %v", err)
+				}
+				if length := len(value.(string)); length < tt.low || length > tt.high {
+					t.Fatalf("GenConstrainedVal() = %q; want length within [%d, %d]", value, tt.low, tt.high)
+				}
+			}
+		})
+	}
+}
+
+func TestGenValsCrossColumnOrdering(t *testing.T) {
+	// the referencing columns come first, so the referenced ones have to be generated before them
+	columns := []model.Column{
+		{Name: "finished_at", Typ: model.Timestamp, Constraints: []model.Constraint{{Op: model.OpGt, Column: "started_at"}}},
+		{Name: "high", Typ: model.Int, Constraints: []model.Constraint{{Op: model.OpGt, Column: "low"}}},
+		{Name: "low", Typ: model.Int, Constraints: []model.Constraint{{Op: model.OpGte, Value: "0"}, {Op: model.OpLt, Value: "100"}}},
+		{Name: "started_at", Typ: model.Timestamp},
+		{Name: "copy", Typ: model.Int, Constraints: []model.Constraint{{Op: model.OpEq, Column: "low"}}},
+	}
+
+	if order := generationOrder(columns); slices.Index(order, 2) > slices.Index(order, 1) || slices.Index(order, 3) > slices.Index(order, 0) || slices.Index(order, 2) > slices.Index(order, 4) {
+		t.Fatalf("generationOrder() = %v; want referenced columns first", order)
+	}
+
+	gen := NewValueGenerator(config.Config{})
+	for iter := range uint32(200) {
+		values, err := gen.GenVals("public.runs", columns, iter)
+		if err != nil {
+			t.Fatalf("GenVals() unexpected error: %v", err)
+		}
+
+		finishedAt, _ := toOrdinal(model.Timestamp, values[0])
+		startedAt, _ := toOrdinal(model.Timestamp, values[3])
+		if finishedAt <= startedAt {
+			t.Fatalf("GenVals() finished_at %v; want after started_at %v", values[0], values[3])
+		}
+		if values[1].(int64) <= values[2].(int64) {
+			t.Fatalf("GenVals() high %v; want greater than low %v", values[1], values[2])
+		}
+		if values[4] != values[2] {
+			t.Fatalf("GenVals() copy %v; want equal to low %v", values[4], values[2])
+		}
+	}
+}
+
+func TestGenConstrainedValUnsatisfiable(t *testing.T) {
+	tests := []struct {
+		name   string
+		column model.Column
+		row    map[string]any
+	}{
+		{name: "empty range", column: model.Column{Typ: model.Int, Constraints: []model.Constraint{{Op: model.OpGt, Value: "10"}, {Op: model.OpLt, Value: "5"}}}},
+		{name: "beyond the type range", column: model.Column{Typ: model.SmallInt, Constraints: []model.Constraint{{Op: model.OpGt, Value: "40000"}}}},
+		{name: "disjoint allowed values", column: model.Column{Typ: model.Text, Constraints: []model.Constraint{{Op: model.OpIn, Values: []string{"a"}}, {Op: model.OpIn, Values: []string{"b"}}}}},
+		{name: "every allowed value excluded", column: model.Column{Typ: model.Text, Constraints: []model.Constraint{{Op: model.OpIn, Values: []string{"a"}}, {Op: model.OpNe, Value: "a"}}}},
+		{name: "length beyond the column length", column: model.Column{Typ: model.Varchar, MaxLength: 3, Constraints: []model.Constraint{{Op: model.OpGte, Value: "5", OnLength: true}}}},
+		{name: "length of a number", column: model.Column{Typ: model.Int, Constraints: []model.Constraint{{Op: model.OpGte, Value: "5", OnLength: true}}}},
+		{name: "below the value of another column", column: model.Column{Typ: model.SmallInt, Constraints: []model.Constraint{{Op: model.OpGt, Column: "low"}}}, row: map[string]any{"low": int64(32767)}},
+	}
+
+	gen := NewValueGenerator(config.Config{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := gen.GenConstrainedVal(tt.column, tt.row)
+			if !errors.Is(err, ErrConstraintNotSatisfiable) {
+				t.Errorf("GenConstrainedVal() = %v, %v; want ErrConstraintNotSatisfiable", value, err)
+			}
+		})
+	}
+}
+
+func TestGenValFitsValuesIntoConstraints(t *testing.T) {
+	tests := []struct {
+		name   string
+		column model.Column
+		check  func(value any) bool
+	}{
+		{
+			name: "range clamped",
+			column: model.Column{Typ: model.Int, Distribution: &model.Distribution{Kind: model.Uniform, Min: model.NumberParam(-100), Max: model.NumberParam(100)},
+				Constraints: []model.Constraint{{Op: model.OpGt, Value: "0"}, {Op: model.OpLte, Value: "10"}}},
+			check: func(value any) bool { return value.(int64) >= 1 && value.(int64) <= 10 },
+		},
+		{
+			name: "date range clamped",
+			column: model.Column{Typ: model.Date, Distribution: &model.Distribution{Kind: model.Uniform, Min: model.TextParam("2023-01-01"), Max: model.TextParam("2025-01-01")},
+				Constraints: []model.Constraint{{Op: model.OpGte, Value: "2024-01-01"}, {Op: model.OpLt, Value: "2024-02-01"}}},
+			check: func(value any) bool { return value.(string) >= "2024-01-01" && value.(string) <= "2024-01-31" },
+		},
+		{
+			name: "categories not allowed replaced",
+			column: model.Column{Typ: model.Varchar, Distribution: &model.Distribution{Kind: model.Categorical, Categories: []model.Category{{Value: "active", Weight: 0.5}, {Value: "legacy", Weight: 0.5}}},
+				Constraints: []model.Constraint{{Op: model.OpIn, Values: []string{"active", "banned"}}}},
+			check: func(value any) bool { return value == "active" || value == "banned" },
+		},
+		{
+			name:   "annotated range clamped",
+			column: model.Column{Typ: model.Int, Annotation: "intRange(1,1000)", Constraints: []model.Constraint{{Op: model.OpLt, Value: "100"}}},
+			check:  func(value any) bool { return value.(int64) >= 1 && value.(int64) <= 99 },
+		},
+		{
+			name:   "annotated values not allowed replaced",
+			column: model.Column{Typ: model.Varchar, MaxLength: 20, Annotation: "oneOf(draft,sent)", Constraints: []model.Constraint{{Op: model.OpIn, Values: []string{"sent", "paid"}}}},
+			check:  func(value any) bool { return value == "sent" || value == "paid" },
+		},
+		{
+			name: "excluded value replaced",
+			column: model.Column{Typ: model.SmallInt, Distribution: &model.Distribution{Kind: model.Uniform, Min: model.NumberParam(0), Max: model.NumberParam(3)},
+				Constraints: []model.Constraint{{Op: model.OpGte, Value: "0"}, {Op: model.OpLte, Value: "3"}, {Op: model.OpNe, Value: "2"}}},
+			check: func(value any) bool { return value.(int64) != 2 },
+		},
+	}
+
+	gen := NewValueGenerator(config.Config{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := gen.Prepare([]model.Table{{Name: "t", Columns: []model.Column{tt.column}}}); err != nil {
+				t.Fatalf("Prepare() unexpected error: %v", err)
+			}
+			for range 200 {
+				value, err := gen.GenVal(tt.column, 0)
+				if err != nil {
+					t.Fatalf("GenVal() unexpected error: %v", err)
+				}
+				if !tt.check(value) {
+					t.Fatalf("GenVal() = %v; want a value satisfying %+v", value, tt.column.Constraints)
+				}
+			}
+		})
+	}
+}
+
+func TestPrepareRejectsUnsatisfiableAnnotatedConstraints(t *testing.T) {
+	column := model.Column{Name: "price", Typ: model.Int, Annotation: "intRange(1,1000)", Constraints: []model.Constraint{
+		{Op: model.OpGt, Value: "100"}, {Op: model.OpLt, Value: "50"},
+	}}
+
+	err := NewValueGenerator(config.Config{}).Prepare([]model.Table{{Name: "t", Schema: "public", Columns: []model.Column{column}}})
+	if !errors.Is(err, ErrConstraintNotSatisfiable) {
+		t.Errorf("Prepare() error = %v; want %v", err, ErrConstraintNotSatisfiable)
+	}
+}
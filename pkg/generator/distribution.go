@@ -0,0 +1,369 @@
+package generator
+
+import (
+	"dbaker/pkg/model"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+var (
+	ErrInvalidDistribution = errors.New("invalid distribution")
+)
+
+// distributionFunc draws a single value of a column with a distribution
+type distributionFunc func() (any, error)
+
+// random wraps the gofakeit source, so that distributions follow its seed, each compiled distribution builds it once
+func random() *rand.Rand {
+	return rand.New(gofakeit.GlobalFaker.Rand)
+}
+
+// compileDistribution validates the distribution parameters against the column type
+// and returns a function drawing values of the column
+func compileDistribution(col model.Column) (distributionFunc, error) {
+	dist := col.Distribution
+	if dist.Kind == model.Categorical {
+		return compileCategorical(col)
+	}
+	if dist.Kind == model.Histogram && len(dist.Categories) > 0 {
+		return compileMostCommon(col)
+	}
+
+	if _, _, ok := typeRange(col.Typ); !ok {
+		return nil, fmt.Errorf("%w: %s distribution on '%s' column", ErrInvalidDistribution, dist.Kind, col.Typ)
+	}
+
+	min, hasMin, err := optionalLocation(col.Typ, "min", dist.Min)
+	if err != nil {
+		return nil, err
+	}
+	max, hasMax, err := optionalLocation(col.Typ, "max", dist.Max)
+	if err != nil {
+		return nil, err
+	}
+	if hasMin && hasMax && min > max {
+		return nil, fmt.Errorf("%w: min %s is greater than max %s", ErrInvalidDistribution, dist.Min, dist.Max)
+	}
+	// these kinds draw offsets from min, which would default to the epoch of temporal columns
+	if isTemporal(col.Typ) && !hasMin && slices.Contains([]model.DistributionKind{model.LogNormal, model.Exponential, model.Zipf, model.Poisson}, dist.Kind) {
+		return nil, fmt.Errorf("%w: %s distribution of a '%s' column requires min", ErrInvalidDistribution, dist.Kind, col.Typ)
+	}
+
+	r := random()
+	var sample func(r *rand.Rand) float64
+	switch dist.Kind {
+	case model.Uniform:
+		if !hasMin || !hasMax {
+			return nil, fmt.Errorf("%w: uniform distribution requires min and max", ErrInvalidDistribution)
+		}
+		sample = func(r *rand.Rand) float64 {
+			return min + r.Float64()*(max-min)
+		}
+
+	case model.Normal:
+		mean, err := location(col.Typ, "mean", dist.Mean)
+		if err != nil {
+			return nil, err
+		}
+		stdDev, err := scale(col.Typ, "stdDev", dist.StdDev)
+		if err != nil {
+			return nil, err
+		}
+		sample = func(r *rand.Rand) float64 {
+			return mean + r.NormFloat64()*stdDev
+		}
+
+	case model.LogNormal:
+		mu, err := number("mu", dist.Mu)
+		if err != nil {
+			return nil, err
+		}
+		sigma, err := positive("sigma", dist.Sigma)
+		if err != nil {
+			return nil, err
+		}
+		sample = func(r *rand.Rand) float64 {
+			return min + math.Exp(mu+r.NormFloat64()*sigma)
+		}
+
+	case model.Exponential:
+		rate, err := positive("rate", dist.Rate)
+		if err != nil {
+			return nil, err
+		}
+		sample = func(r *rand.Rand) float64 {
+			return min + r.ExpFloat64()/rate
+		}
+
+	case model.Zipf:
+		s, err := number("s", dist.S)
+		if err != nil {
+			return nil, err
+		}
+		v := 1.0
+		if dist.V != nil {
+			v = *dist.V
+		}
+		if s <= 1 || v < 1 {
+			return nil, fmt.Errorf("%w: zipf distribution requires s > 1 and v >= 1", ErrInvalidDistribution)
+		}
+		if !hasMax {
+			return nil, fmt.Errorf("%w: zipf distribution requires max", ErrInvalidDistribution)
+		}
+		zipf := rand.NewZipf(r, s, v, uint64(max-min))
+		sample = func(_ *rand.Rand) float64 {
+			return min + float64(zipf.Uint64())
+		}
+
+	case model.Poisson:
+		lambda, err := positive("lambda", dist.Lambda)
+		if err != nil {
+			return nil, err
+		}
+		sample = func(r *rand.Rand) float64 {
+			return min + poisson(r, lambda)
+		}
+
+	case model.Histogram:
+		if len(dist.Bounds) < 2 {
+			return nil, fmt.Errorf("%w: histogram distribution requires at least two bounds", ErrInvalidDistribution)
+		}
+		bounds := make([]float64, len(dist.Bounds))
+		for index := range dist.Bounds {
+			if bounds[index], err = location(col.Typ, "bounds", &dist.Bounds[index]); err != nil {
+				return nil, err
+			}
+			if index > 0 && bounds[index] < bounds[index-1] {
+				return nil, fmt.Errorf("%w: histogram bounds must be ascending", ErrInvalidDistribution)
+			}
+		}
+		sample = func(r *rand.Rand) float64 {
+			bucket := r.IntN(len(bounds) - 1)
+			return bounds[bucket] + r.Float64()*(bounds[bucket+1]-bounds[bucket])
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: unknown kind '%s'", ErrInvalidDistribution, dist.Kind)
+	}
+
+	typeLow, typeHigh, _ := typeRange(col.Typ)
+	return func() (any, error) {
+		ordinal := sample(r)
+		if hasMin {
+			ordinal = math.Max(ordinal, min)
+		}
+		if hasMax {
+			ordinal = math.Min(ordinal, max)
+		}
+		if !isFloat(col.Typ) {
+			ordinal = math.Round(ordinal)
+		}
+		if !isTemporal(col.Typ) {
+			ordinal = math.Max(typeLow, math.Min(typeHigh, ordinal))
+		}
+		return fromOrdinal(col.Typ, ordinal), nil
+	}, nil
+}
+
+func compileCategorical(col model.Column) (distributionFunc, error) {
+	categories := col.Distribution.Categories
+	if len(categories) == 0 {
+		return nil, fmt.Errorf("%w: categorical distribution requires categories", ErrInvalidDistribution)
+	}
+
+	values := make([]any, len(categories))
+	cumulative := make([]float64, len(categories))
+	total := 0.0
+	for index, category := range categories {
+		if category.Weight < 0 {
+			return nil, fmt.Errorf("%w: category '%s' has a negative weight", ErrInvalidDistribution, category.Value)
+		}
+
+		value, err := parseLiteral(col.Typ, category.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: category '%s' is not a valid %s: %w", ErrInvalidDistribution, category.Value, col.Typ, err)
+		}
+
+		total += category.Weight
+		values[index] = value
+		cumulative[index] = total
+	}
+
+	if total == 0 {
+		return nil, fmt.Errorf("%w: category weights sum up to zero", ErrInvalidDistribution)
+	}
+
+	r := random()
+	return func() (any, error) {
+		target := r.Float64() * total
+		for index, limit := range cumulative {
+			if target < limit {
+				return values[index], nil
+			}
+		}
+		return values[len(values)-1], nil
+	}, nil
+}
+
+// compileMostCommon compiles a histogram along with its most common values, which are drawn with their weight,
+// the buckets cover the remaining values
+func compileMostCommon(col model.Column) (distributionFunc, error) {
+	common, err := compileCategorical(col)
+	if err != nil {
+		return nil, err
+	}
+
+	var weight float64
+	for _, category := range col.Distribution.Categories {
+		weight += category.Weight
+	}
+	if weight > 1 {
+		return nil, fmt.Errorf("%w: weights of the most common values of a histogram sum up to %v, more than 1", ErrInvalidDistribution, weight)
+	}
+
+	histogram := *col.Distribution
+	histogram.Categories = nil
+	rest := col
+	rest.Distribution = &histogram
+	remaining, err := compileDistribution(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	r := random()
+	return func() (any, error) {
+		if r.Float64() < weight {
+			return common()
+		}
+		return remaining()
+	}, nil
+}
+
+// poisson draws from the poisson distribution, large lambdas are approximated by the normal distribution
+func poisson(r *rand.Rand, lambda float64) float64 {
+	if lambda > 30 {
+		return math.Max(0, math.Round(lambda+r.NormFloat64()*math.Sqrt(lambda)))
+	}
+
+	limit := math.Exp(-lambda)
+	k := 0.0
+	for p := r.Float64(); p > limit; p *= r.Float64() {
+		k++
+	}
+
+	return k
+}
+
+// location resolves a parameter positioned in the column value space (min, max, mean),
+// temporal columns take dates and timestamps, numeric columns take numbers
+func location(typ model.ColumnType, name string, param *model.Param) (float64, error) {
+	if param == nil {
+		return 0, fmt.Errorf("%w: missing parameter '%s'", ErrInvalidDistribution, name)
+	}
+
+	if isTemporal(typ) != param.IsText() {
+		expected := "a number"
+		if isTemporal(typ) {
+			expected = "a date or timestamp string"
+		}
+		return 0, fmt.Errorf("%w: parameter '%s' of '%s' column must be %s", ErrInvalidDistribution, name, typ, expected)
+	}
+
+	if !param.IsText() {
+		if math.IsNaN(param.Number) || math.IsInf(param.Number, 0) {
+			return 0, fmt.Errorf("%w: parameter '%s' must be a finite number", ErrInvalidDistribution, name)
+		}
+		return param.Number, nil
+	}
+
+	ordinal, err := toOrdinal(typ, param.Text)
+	if err != nil {
+		return 0, fmt.Errorf("%w: parameter '%s': %w", ErrInvalidDistribution, name, err)
+	}
+
+	return ordinal, nil
+}
+
+func optionalLocation(typ model.ColumnType, name string, param *model.Param) (float64, bool, error) {
+	if param == nil {
+		return 0, false, nil
+	}
+
+	value, err := location(typ, name, param)
+	return value, err == nil, err
+}
+
+// scale resolves a parameter measuring distance in the column value space (stdDev),
+// temporal columns take durations like "36h" or "30d", numeric columns take numbers
+func scale(typ model.ColumnType, name string, param *model.Param) (float64, error) {
+	if param == nil {
+		return 0, fmt.Errorf("%w: missing parameter '%s'", ErrInvalidDistribution, name)
+	}
+
+	value := param.Number
+	if isTemporal(typ) {
+		if !param.IsText() {
+			return 0, fmt.Errorf("%w: parameter '%s' of '%s' column must be a duration string", ErrInvalidDistribution, name, typ)
+		}
+
+		duration, err := parseDuration(param.Text)
+		if err != nil {
+			return 0, fmt.Errorf("%w: parameter '%s': %w", ErrInvalidDistribution, name, err)
+		}
+
+		value = duration.Seconds()
+		if typ == model.Date {
+			value /= secondsPerDay
+		}
+	} else if param.IsText() {
+		return 0, fmt.Errorf("%w: parameter '%s' of '%s' column must be a number", ErrInvalidDistribution, name, typ)
+	}
+
+	if value <= 0 {
+		return 0, fmt.Errorf("%w: parameter '%s' must be positive", ErrInvalidDistribution, name)
+	}
+
+	return value, nil
+}
+
+// parseDuration extends time.ParseDuration with days, e.g. "30d"
+func parseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		count, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s'", value)
+		}
+		return time.Duration(count * secondsPerDay * float64(time.Second)), nil
+	}
+
+	return time.ParseDuration(value)
+}
+
+func number(name string, value *float64) (float64, error) {
+	if value == nil {
+		return 0, fmt.Errorf("%w: missing parameter '%s'", ErrInvalidDistribution, name)
+	}
+
+	return *value, nil
+}
+
+func positive(name string, value *float64) (float64, error) {
+	number, err := number(name, value)
+	if err != nil {
+		return 0, err
+	}
+
+	if number <= 0 {
+		return 0, fmt.Errorf("%w: parameter '%s' must be positive", ErrInvalidDistribution, name)
+	}
+
+	return number, nil
+}
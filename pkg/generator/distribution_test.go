@@ -0,0 +1,167 @@
+package generator
+
+import (
+	"dbaker/pkg/config"
+	"dbaker/pkg/model"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCompileDistributionValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		column  string
+		wantErr bool
+	}{
+		{name: "uniform", column: `{"columnType": "int4", "distribution": {"kind": "uniform", "min": 1, "max": 10}}`},
+		{name: "uniform without max", column: `{"columnType": "int4", "distribution": {"kind": "uniform", "min": 1}}`, wantErr: true},
+		{name: "uniform min over max", column: `{"columnType": "int4", "distribution": {"kind": "uniform", "min": 10, "max": 1}}`, wantErr: true},
+		{name: "normal", column: `{"columnType": "double", "distribution": {"kind": "normal", "mean": 10, "stdDev": 2}}`},
+		{name: "normal zero deviation", column: `{"columnType": "double", "distribution": {"kind": "normal", "mean": 10, "stdDev": 0}}`, wantErr: true},
+		{name: "temporal normal", column: `{"columnType": "timestamp", "distribution": {"kind": "normal", "mean": "2024-01-01", "stdDev": "30d"}}`},
+		{name: "temporal mean as number", column: `{"columnType": "date", "distribution": {"kind": "normal", "mean": 10, "stdDev": "1d"}}`, wantErr: true},
+		{name: "lognormal", column: `{"columnType": "bigint", "distribution": {"kind": "lognormal", "mu": 3, "sigma": 1}}`},
+		{name: "exponential without rate", column: `{"columnType": "int4", "distribution": {"kind": "exponential"}}`, wantErr: true},
+		{name: "zipf", column: `{"columnType": "int4", "distribution": {"kind": "zipf", "s": 1.5, "max": 1000}}`},
+		{name: "zipf invalid s", column: `{"columnType": "int4", "distribution": {"kind": "zipf", "s": 1, "max": 1000}}`, wantErr: true},
+		{name: "poisson", column: `{"columnType": "smallint", "distribution": {"kind": "poisson", "lambda": 4}}`},
+		{name: "categorical", column: `{"columnType": "varchar", "distribution": {"kind": "categorical", "categories": [{"value": "a", "weight": 3}, {"value": "b", "weight": 1}]}}`},
+		{name: "categorical invalid value", column: `{"columnType": "int4", "distribution": {"kind": "categorical", "categories": [{"value": "a", "weight": 1}]}}`, wantErr: true},
+		{name: "categorical zero weights", column: `{"columnType": "int4", "distribution": {"kind": "categorical", "categories": [{"value": "1", "weight": 0}]}}`, wantErr: true},
+		{name: "numeric distribution on text", column: `{"columnType": "text", "distribution": {"kind": "uniform", "min": 1, "max": 2}}`, wantErr: true},
+		{name: "unknown kind", column: `{"columnType": "int4", "distribution": {"kind": "pareto"}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var column model.Column
+			if err := json.Unmarshal([]byte(tt.column), &column); err != nil {
+				t.Fatalf("failed to unmarshal column: %v", err)
+			}
+
+			_, err := compileDistribution(column)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compileDistribution() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidDistribution) {
+				t.Errorf("compileDistribution() error = %v; want ErrInvalidDistribution", err)
+			}
+		})
+	}
+}
+
+func TestDistributionSamplesWithinBounds(t *testing.T) {
+	column := model.Column{
+		Typ: model.SmallInt,
+		Distribution: &model.Distribution{
+			Kind:   model.Normal,
+			Mean:   model.NumberParam(0),
+			StdDev: model.NumberParam(1e6),
+			Min:    model.NumberParam(-10),
+			Max:    model.NumberParam(10),
+		},
+	}
+
+	fn, err := compileDistribution(column)
+	if err != nil {
+		t.Fatalf("compileDistribution() unexpected error: %v", err)
+	}
+
+	for range 100 {
+		value, err := fn()
+		if err != nil {
+			t.Fatalf("sample unexpected error: %v", err)
+		}
+		if v := value.(int64); v < -10 || v > 10 {
+			t.Fatalf("sample = %d; want value within [-10, 10]", v)
+		}
+	}
+}
+
+func TestTemporalDistributionSamples(t *testing.T) {
+	column := model.Column{
+		Typ: model.Date,
+		Distribution: &model.Distribution{
+			Kind: model.Uniform,
+			Min:  model.TextParam("2024-01-01"),
+			Max:  model.TextParam("2024-01-31"),
+		},
+	}
+
+	fn, err := compileDistribution(column)
+	if err != nil {
+		t.Fatalf("compileDistribution() unexpected error: %v", err)
+	}
+
+	for range 100 {
+		value, err := fn()
+		if err != nil {
+			t.Fatalf("sample unexpected error: %v", err)
+		}
+		date, err := time.Parse("2006-01-02", value.(string))
+		if err != nil {
+			t.Fatalf("sample = %v; want a date: %v", value, err)
+		}
+		if date.Year() != 2024 || date.Month() != time.January {
+			t.Fatalf("sample = %v; want a date in January 2024", value)
+		}
+	}
+}
+
+func TestParamJSONRoundTrip(t *testing.T) {
+	input := `{"kind":"uniform","min":"2024-01-01","max":1.5}`
+
+	var dist model.Distribution
+	if err := json.Unmarshal([]byte(input), &dist); err != nil {
+		t.Fatalf("failed to unmarshal distribution: %v", err)
+	}
+
+	output, err := json.Marshal(dist)
+	if err != nil {
+		t.Fatalf("failed to marshal distribution: %v", err)
+	}
+
+	if string(output) != input {
+		t.Errorf("json round trip = %s; want %s", output, input)
+	}
+}
+
+func TestTemporalOffsetDistributionsRequireMin(t *testing.T) {
+	rate := 0.1
+	column := model.Column{Typ: model.Timestamp, Distribution: &model.Distribution{Kind: model.Exponential, Rate: &rate}}
+	if _, err := compileDistribution(column); !errors.Is(err, ErrInvalidDistribution) {
+		t.Fatalf("compileDistribution() error = %v; want %v", err, ErrInvalidDistribution)
+	}
+
+	column.Distribution.Min = model.TextParam("2024-01-01")
+	fn, err := compileDistribution(column)
+	if err != nil {
+		t.Fatalf("compileDistribution() unexpected error: %v", err)
+	}
+	value, err := fn()
+	if err != nil || value.(string) < "2024-01-01" {
+		t.Errorf("sample = %v, %v; want a timestamp from 2024-01-01 on", value, err)
+	}
+}
+
+func TestPrepareRejectsIgnoredDistributions(t *testing.T) {
+	uniform := &model.Distribution{Kind: model.Uniform, Min: model.NumberParam(0), Max: model.NumberParam(10)}
+	tests := []struct {
+		name   string
+		column model.Column
+	}{
+		{name: "unique column", column: model.Column{Name: "a", Typ: model.Int, IsUnique: true, Distribution: uniform}},
+		{name: "annotated column", column: model.Column{Name: "a", Typ: model.Int, Annotation: "intRange(1,5)", Distribution: uniform}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables := []model.Table{{Name: "t", Schema: "public", Columns: []model.Column{tt.column}}}
+			if err := NewValueGenerator(config.Config{}).Prepare(tables); err == nil {
+				t.Errorf("Prepare() error = nil; want the distribution rejected")
+			}
+		})
+	}
+}
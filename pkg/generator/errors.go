@@ -0,0 +1,29 @@
+package generator
+
+import (
+	"dbaker/pkg/model"
+	"fmt"
+)
+
+// UnsupportedTypeError reports a column whose type no value can be generated for, it matches ErrColumnTypeNotSupported
+type UnsupportedTypeError struct {
+	Table  string
+	Column string
+	Type   model.ColumnType
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return fmt.Sprintf("table '%s', column '%s': %s '%s', remove it from the recipe to leave it to its default value, or mark it as generated", e.Table, e.Column, ErrColumnTypeNotSupported, e.Type)
+}
+
+func (e *UnsupportedTypeError) Is(target error) bool {
+	return target == ErrColumnTypeNotSupported
+}
+
+// supportedTypes are the column types values can be generated for
+var supportedTypes = []model.ColumnType{
+	model.SmallInt, model.Int, model.BigInt, model.Real, model.Double,
+	model.Char, model.Varchar, model.Text,
+	model.UUID, model.Boolean,
+	model.Date, model.Time, model.Timestamp, model.TimestampTZ,
+}
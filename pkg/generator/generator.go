@@ -1,11 +1,13 @@
 package generator
 
 import (
+	"dbaker/pkg/config"
 	"dbaker/pkg/model"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand/v2"
-	"strconv"
+	"slices"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v7"
@@ -20,35 +22,223 @@ var (
  * Out of scope: database connection, writing of values.
  * Should be rather databse agnostic, might leverage a specific database writer (PostgreSQL data writer)
  */
-type ValueGenerator struct{}
+type ValueGenerator struct {
+	annotations   map[string]annotationFunc
+	distributions map[*model.Distribution]distributionFunc
+	nullRatio     float64
+	// scramble unique values by a permutation keyed by the seed
+	scramble bool
+	seed     uint64
+}
+
+func NewValueGenerator(config config.Config) *ValueGenerator {
+	return &ValueGenerator{
+		annotations:   make(map[string]annotationFunc),
+		distributions: make(map[*model.Distribution]distributionFunc),
+		nullRatio:     config.NullRatio,
+		scramble:      config.Scramble,
+		seed:          config.Seed,
+	}
+}
 
-func (g *ValueGenerator) GenVals(cols []model.Column, iter uint32) ([]any, error) {
-	// for each column generate value
-	var values []any
-	for _, col := range cols {
-		value, err := g.GenVal(col, iter)
+// Prepare resolves and validates annotations, distributions and null ratios of all columns up front,
+// every unsupported column type and unknown or invalid setting is reported at once
+func (g *ValueGenerator) Prepare(tables []model.Table) error {
+	var errs []error
+	if g.nullRatio < 0 || g.nullRatio > 1 {
+		errs = append(errs, fmt.Errorf("default null ratio %v is not within <0, 1>", g.nullRatio))
+	}
+
+	for _, table := range tables {
+		for _, col := range table.Columns {
+			// generated columns are left to the database
+			if col.IsGenerated {
+				continue
+			}
+			if !slices.Contains(supportedTypes, col.Typ) {
+				errs = append(errs, &UnsupportedTypeError{table.Schema + "." + table.Name, col.Name, col.Typ})
+				continue
+			}
+			if err := g.prepareColumn(col); err != nil {
+				errs = append(errs, fmt.Errorf("table '%s.%s', column '%s': %w", table.Schema, table.Name, col.Name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (g *ValueGenerator) prepareColumn(col model.Column) error {
+	if col.NullRatio != nil {
+		if *col.NullRatio < 0 || *col.NullRatio > 1 {
+			return fmt.Errorf("null ratio %v is not within <0, 1>", *col.NullRatio)
+		}
+		if *col.NullRatio > 0 && !col.IsNullable {
+			return fmt.Errorf("null ratio set on a column which is not nullable")
+		}
+	}
+
+	if col.Distribution != nil {
+		// unique values and annotations take precedence, the distribution would be ignored
+		if col.IsUnique {
+			return fmt.Errorf("distribution set on a unique column, its values are derived from the iteration")
+		}
+		if col.Annotation != "" && col.Annotation != NoInferAnnotation {
+			return fmt.Errorf("both annotation '%s' and a distribution set, keep one of them", col.Annotation)
+		}
+		if err := g.resolveDistribution(col); err != nil {
+			return err
+		}
+	}
+
+	if col.Annotation == "" || col.Annotation == NoInferAnnotation {
+		return nil
+	}
+
+	// unique values of semantic annotations are generated by the unique generators
+	if generator, ok := uniqueGeneratorOf(col); ok && col.IsUnique {
+		return prepareUniqueGenerator(col, generator)
+	}
+
+	if err := g.resolveAnnotation(col.Annotation); err != nil {
+		return err
+	}
+
+	// a sample catches annotations whose values don't fit the column type (e.g. email of an int column)
+	// or its check constraints before any row is generated
+	value, err := g.GenAnnotatedVal(col)
+	if err != nil || len(col.Constraints) == 0 {
+		return err
+	}
+	_, err = g.constrainVal(col, value, nil)
+	return err
+}
+
+func (g *ValueGenerator) resolveAnnotation(annotation string) error {
+	if _, ok := g.annotations[annotation]; ok {
+		return nil
+	}
+
+	fn, err := compileAnnotation(annotation)
+	if err != nil {
+		return err
+	}
+
+	slog.Debug("annotation compiled", "annotation", annotation)
+	g.annotations[annotation] = fn
+	return nil
+}
+
+// GenVals generates the values of a row of the table (schema.name), which keys the unique values
+func (g *ValueGenerator) GenVals(table string, cols []model.Column, iter uint32) ([]any, error) {
+	// for each column generate value, columns referenced by check constraints go first
+	values := make([]any, len(cols))
+	row := make(map[string]any, len(cols))
+	for _, index := range generationOrder(cols) {
+		col := cols[index]
+		value, err := g.genVal(table, col, iter, row)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate value for column '%s(%s)': %w", col.Name, col.Typ, err)
 		}
 
-		values = append(values, value)
+		values[index] = value
+		row[col.Name] = value
 	}
 
 	return values, nil
 }
 
 func (g *ValueGenerator) GenVal(col model.Column, iter uint32) (any, error) {
+	return g.genVal("", col, iter, nil)
+}
+
+func (g *ValueGenerator) genVal(table string, col model.Column, iter uint32, row map[string]any) (any, error) {
+	// unique nullable columns may hold NULL too, postgres does not consider NULLs equal
+	if g.isNull(col) {
+		return nil, nil
+	}
+
 	if col.IsUnique {
-		return g.GenUniqueVal(col, iter)
+		return g.GenUniqueVal(table, col, iter)
+	}
+
+	// annotations and distributions don't know the check constraints, their values are fit into them
+	if col.Annotation != "" && col.Annotation != NoInferAnnotation {
+		value, err := g.GenAnnotatedVal(col)
+		if err != nil || len(col.Constraints) == 0 {
+			return value, err
+		}
+		return g.constrainVal(col, value, row)
+	}
+
+	if col.Distribution != nil {
+		value, err := g.GenDistributedVal(col)
+		if err != nil || len(col.Constraints) == 0 {
+			return value, err
+		}
+		return g.constrainVal(col, value, row)
+	}
+
+	if len(col.Constraints) > 0 {
+		return g.GenConstrainedVal(col, row)
 	}
 
 	return g.GenRawVal(col)
 }
 
-// 1. look at the annotation (use annotation logic)
-// 2. if no annotation try inferring meaning base on name heurestically
+func (g *ValueGenerator) resolveDistribution(col model.Column) error {
+	if _, ok := g.distributions[col.Distribution]; ok {
+		return nil
+	}
+
+	fn, err := compileDistribution(col)
+	if err != nil {
+		return err
+	}
+
+	slog.Debug("distribution compiled", "column", col.Name, "kind", col.Distribution.Kind)
+	g.distributions[col.Distribution] = fn
+	return nil
+}
+
+func (g *ValueGenerator) isNull(col model.Column) bool {
+	if !col.IsNullable {
+		return false
+	}
+
+	ratio := g.nullRatio
+	if col.NullRatio != nil {
+		ratio = *col.NullRatio
+	}
+
+	return ratio > 0 && gofakeit.Float64() < ratio
+}
+
+func (g *ValueGenerator) GenDistributedVal(col model.Column) (any, error) {
+	if err := g.resolveDistribution(col); err != nil {
+		return nil, err
+	}
+
+	return g.distributions[col.Distribution]()
+}
+
+func (g *ValueGenerator) GenAnnotatedVal(col model.Column) (any, error) {
+	if err := g.resolveAnnotation(col.Annotation); err != nil {
+		return nil, err
+	}
+
+	value, err := g.annotations[col.Annotation]()
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate annotation '%s': %w", col.Annotation, err)
+	}
+
+	return coerceVal(col, value)
+}
+
+// 1. look at the annotation (use annotation logic, see GenAnnotatedVal)
+// 2. if no annotation try inferring meaning base on name heurestically (done during introspect, see InferAnnotation)
 // 3. if no-infer tag or not possible to infer use generic type inference
-// Initial implemetation does only generic type inference
+// GenRawVal does only the generic type inference
 func (g *ValueGenerator) GenRawVal(col model.Column) (any, error) {
 	switch col.Typ {
 	case model.SmallInt:
@@ -91,51 +281,3 @@ func (g *ValueGenerator) GenRawVal(col model.Column) (any, error) {
 		return nil, ErrColumnTypeNotSupported
 	}
 }
-
-func (g *ValueGenerator) GenUniqueVal(col model.Column, iter uint32) (any, error) {
-	switch col.Typ {
-	case model.SmallInt:
-		fallthrough
-	case model.Int:
-		fallthrough
-	case model.BigInt:
-		return iter, nil
-	case model.Real:
-		return float32(iter), nil
-	case model.Double:
-		return float64(iter), nil
-
-	case model.Char:
-		fallthrough
-	case model.Varchar:
-		iterDigits := uint(len(strconv.FormatUint(uint64(iter), 10)))
-		return fmt.Sprintf("%d%s", iter, gofakeit.LetterN(col.MaxLength-iterDigits)), nil
-	case model.Text:
-		return fmt.Sprintf("%d%s", iter, gofakeit.Sentence(rand.IntN(10-1)+1)), nil
-
-	case model.UUID:
-		return gofakeit.UUID(), nil
-	case model.Boolean:
-		return iter%2 == 0, nil
-
-	case model.Date:
-		// Generate a unique date by adding iter days to a base date
-		base := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
-		return base.AddDate(0, 0, int(iter)).Format("2006-01-02"), nil
-	case model.Time:
-		// Generate a unique time by adding iter seconds to midnight
-		base := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
-		return base.Add(time.Duration(iter) * time.Second).Format("15:04:05"), nil
-	case model.Timestamp:
-		// Generate a unique timestamp by adding iter seconds to a base
-		base := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
-		return base.Add(time.Duration(iter) * time.Second).Format(time.RFC3339), nil
-	case model.TimestampTZ:
-		// Generate a unique timestamp with timezone by adding iter seconds
-		base := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
-		return base.Add(time.Duration(iter) * time.Second).Format(time.RFC3339), nil
-
-	default:
-		return nil, ErrColumnTypeNotSupported
-	}
-}
@@ -0,0 +1,182 @@
+package generator
+
+import (
+	"dbaker/pkg/config"
+	"dbaker/pkg/model"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGenValNullRatio(t *testing.T) {
+	always, never := 1.0, 0.0
+	tests := []struct {
+		name         string
+		defaultRatio float64
+		column       model.Column
+		expectNull   bool
+	}{
+		{name: "default ratio", defaultRatio: 1, column: model.Column{Typ: model.Int, IsNullable: true}, expectNull: true},
+		{name: "column ratio overrides default", defaultRatio: 1, column: model.Column{Typ: model.Int, IsNullable: true, NullRatio: &never}, expectNull: false},
+		{name: "column ratio", column: model.Column{Typ: model.Int, IsNullable: true, NullRatio: &always}, expectNull: true},
+		{name: "unique nullable column", column: model.Column{Typ: model.Int, IsNullable: true, IsUnique: true, NullRatio: &always}, expectNull: true},
+		{name: "not nullable column", defaultRatio: 1, column: model.Column{Typ: model.Int}, expectNull: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewValueGenerator(config.Config{NullRatio: tt.defaultRatio})
+			value, err := gen.GenVal(tt.column, 1)
+			if err != nil {
+				t.Fatalf("GenVal() unexpected error: %v", err)
+			}
+			if (value == nil) != tt.expectNull {
+				t.Errorf("GenVal() = %v; want null %v", value, tt.expectNull)
+			}
+		})
+	}
+}
+
+func TestPrepareValidatesNullRatio(t *testing.T) {
+	invalid, half := 1.5, 0.5
+	tests := []struct {
+		name    string
+		column  model.Column
+		wantErr bool
+	}{
+		{name: "valid ratio", column: model.Column{Name: "a", Typ: model.Int, IsNullable: true, NullRatio: &half}},
+		{name: "ratio out of range", column: model.Column{Name: "a", Typ: model.Int, IsNullable: true, NullRatio: &invalid}, wantErr: true},
+		{name: "ratio on not nullable column", column: model.Column{Name: "a", Typ: model.Int, NullRatio: &half}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables := []model.Table{{Name: "t", Schema: "public", Columns: []model.Column{tt.column}}}
+			err := NewValueGenerator(config.Config{}).Prepare(tables)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Prepare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrepareChecksAnnotatedValuesFitTheColumn(t *testing.T) {
+	tests := []struct {
+		name    string
+		column  model.Column
+		wantErr bool
+	}{
+		{name: "email of a varchar column", column: model.Column{Name: "a", Typ: model.Varchar, MaxLength: 100, Annotation: "email"}},
+		{name: "range of an int column", column: model.Column{Name: "a", Typ: model.Int, Annotation: "intRange(1,10)"}},
+		{name: "email of an int column", column: model.Column{Name: "a", Typ: model.Int, Annotation: "email"}, wantErr: true},
+		{name: "words of a date column", column: model.Column{Name: "a", Typ: model.Date, Annotation: "oneOf(soon,later)"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables := []model.Table{{Name: "t", Schema: "public", Columns: []model.Column{tt.column}}}
+			err := NewValueGenerator(config.Config{}).Prepare(tables)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Prepare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrepareReportsAllUnsupportedTypes(t *testing.T) {
+	tables := []model.Table{
+		{Name: "users", Schema: "public", Columns: []model.Column{
+			{Name: "id", Typ: model.Int},
+			{Name: "balance", Typ: model.Decimal},
+			// generated columns are left to the database
+			{Name: "search", Typ: "tsvector", IsGenerated: true},
+		}},
+		{Name: "orders", Schema: "shop", Columns: []model.Column{{Name: "items", Typ: "jsonb"}}},
+	}
+
+	err := NewValueGenerator(config.Config{}).Prepare(tables)
+	if !errors.Is(err, ErrColumnTypeNotSupported) {
+		t.Fatalf("Prepare() error = %v; want ErrColumnTypeNotSupported", err)
+	}
+
+	var unsupported []string
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var typeErr *UnsupportedTypeError
+		if errors.As(err, &typeErr) {
+			unsupported = append(unsupported, typeErr.Table+"."+typeErr.Column)
+		}
+	}
+	if want := []string{"public.users.balance", "shop.orders.items"}; !slices.Equal(unsupported, want) {
+		t.Errorf("Prepare() unsupported columns = %v; want %v", unsupported, want)
+	}
+}
+
+func TestNextUniqueIterInvertsGenUniqueVal(t *testing.T) {
+	gen := NewValueGenerator(config.Config{})
+	columns := []model.Column{
+		{Name: "id", Typ: model.Int, IsUnique: true},
+		{Name: "positive", Typ: model.BigInt, IsUnique: true, Constraints: []model.Constraint{{Op: model.OpGte, Value: "1000"}}},
+		{Name: "score", Typ: model.Double, IsUnique: true},
+		{Name: "day", Typ: model.Date, IsUnique: true},
+		{Name: "at", Typ: model.TimestampTZ, IsUnique: true},
+	}
+
+	for _, col := range columns {
+		t.Run(col.Name, func(t *testing.T) {
+			value, err := gen.GenUniqueVal("public.t", col, 41)
+			if err != nil {
+				t.Fatalf("GenUniqueVal() unexpected error: %v", err)
+			}
+
+			// the database hands the values back in their scanned types
+			switch v := value.(type) {
+			case uint32:
+				value = int64(v)
+			case string:
+				parsed, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					parsed, _ = time.Parse("2006-01-02", v)
+				}
+				value = parsed
+			}
+
+			next, err := NextUniqueIter(col, value)
+			if err != nil {
+				t.Fatalf("NextUniqueIter() unexpected error: %v", err)
+			}
+			if next != 42 {
+				t.Errorf("NextUniqueIter(%v) = %d; want 42", value, next)
+			}
+		})
+	}
+}
+
+func TestNextUniqueIter(t *testing.T) {
+	testCases := []struct {
+		name    string
+		col     model.Column
+		value   any
+		want    uint32
+		wantErr bool
+	}{
+		{name: "empty table", col: model.Column{Typ: model.Int}, value: nil, want: 0},
+		{name: "leading number of text", col: model.Column{Typ: model.Varchar}, value: int64(99), want: 100},
+		{name: "values below the lower bound", col: model.Column{Typ: model.Int, Constraints: []model.Constraint{{Op: model.OpGt, Value: "10"}}}, value: int64(-5), want: 0},
+		{name: "dates centuries after the base", col: model.Column{Typ: model.Date}, value: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 199999), want: 200000},
+		{name: "beyond the last iteration", col: model.Column{Typ: model.BigInt}, value: int64(1 << 40), wantErr: true},
+		{name: "unsupported value", col: model.Column{Typ: model.UUID}, value: "4f1c...", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			next, err := NextUniqueIter(tc.col, tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("NextUniqueIter() error = %v; wantErr %t", err, tc.wantErr)
+			}
+			if next != tc.want {
+				t.Errorf("NextUniqueIter() = %d; want %d", next, tc.want)
+			}
+		})
+	}
+}
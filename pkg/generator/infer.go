@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"dbaker/pkg/model"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// NoInferAnnotation marks a column which should not get an inferred annotation,
+// values of such column are generated based on the column type only
+const NoInferAnnotation = "no-infer"
+
+var (
+	textTypes     = []model.ColumnType{model.Char, model.Varchar, model.Text}
+	intTypes      = []model.ColumnType{model.SmallInt, model.Int, model.BigInt}
+	floatTypes    = []model.ColumnType{model.Real, model.Double}
+	temporalTypes = []model.ColumnType{model.Date, model.Timestamp, model.TimestampTZ}
+)
+
+// inferenceRule maps column names matching the pattern onto an annotation,
+// the rule applies only to columns of the listed types
+type inferenceRule struct {
+	pattern    *regexp.Regexp
+	types      []model.ColumnType
+	annotation string
+}
+
+func rule(pattern string, annotation string, types ...[]model.ColumnType) inferenceRule {
+	return inferenceRule{
+		pattern:    regexp.MustCompile(pattern),
+		types:      slices.Concat(types...),
+		annotation: annotation,
+	}
+}
+
+// rules are matched against the normalized column name (snake_case) in order, first match wins
+var inferenceRules = []inferenceRule{
+	rule(`^(first_?name|fname|given_name|forename)$`, "firstName", textTypes),
+	rule(`^(last_?name|lname|surname|family_name)$`, "lastName", textTypes),
+	rule(`^(full_?name|display_name|contact_name|person_name)$`, "name", textTypes),
+	rule(`^(user_?name|login|nickname|handle)$`, "username", textTypes),
+	rule(`(^|_)e_?mail(_address)?$`, "email", textTypes),
+	rule(`(^|_)(phone|mobile|telephone|tel|cell)(_number|_no)?$`, "phone", textTypes),
+	rule(`(^|_)(street|address|address_line_?\d?)$`, "street", textTypes),
+	rule(`(^|_)(city|town)$`, "city", textTypes),
+	rule(`(^|_)(state|province)$`, "state", textTypes),
+	rule(`(^|_)country_(code|iso)$`, "countryAbr", textTypes),
+	rule(`(^|_)country$`, "country", textTypes),
+	rule(`(^|_)(zip|zip_?code|postal_?code|post_?code)$`, "zip", textTypes),
+	rule(`(^|_)(url|website|homepage|link)$`, "url", textTypes),
+	rule(`(^|_)ip(_?address)?$`, "ipv4Address", textTypes),
+	rule(`^(company|company_name|organization|organisation|employer)$`, "company", textTypes),
+	rule(`^(job_?title|position)$`, "jobTitle", textTypes),
+	rule(`^(gender|sex)$`, "gender", textTypes),
+	rule(`^(currency|currency_code)$`, "currencyShort", textTypes),
+	rule(`(^|_)colou?r$`, "color", textTypes),
+	rule(`^(description|bio|summary|comment|comments|notes?|remarks?)$`, "sentence(12)", textTypes),
+	rule(`^(birth_?date|date_of_birth|dob|birthday|born(_at|_on)?)$`, "dateRange(1950-01-01,2005-12-31)", temporalTypes),
+	rule(`(_at|_on|_date|_time)$|^(date|created|updated|modified|deleted|timestamp)$`, "pastDate", temporalTypes),
+	rule(`(^|_)(lat|latitude)$`, "latitude", floatTypes),
+	rule(`(^|_)(lng|lon|long|longitude)$`, "longitude", floatTypes),
+	rule(`(^|_)(price|amount|cost|total|balance|fee|salary)$`, "price(1,1000)", floatTypes),
+	rule(`(^|_)(price|amount|cost|total|balance|fee|salary)$`, "intRange(1,1000)", intTypes),
+	rule(`^age$`, "intRange(18,90)", intTypes),
+	rule(`(^|_)(quantity|qty|count)$`, "intRange(1,100)", intTypes),
+}
+
+// uniqueInferenceRules apply to unique columns only, their annotations have no other than unique values
+var uniqueInferenceRules = []inferenceRule{
+	rule(`(^|_)slug$`, "slug", textTypes),
+}
+
+// InferAnnotation infers the annotation from the column name, returns an empty
+// string when the name is not recognized or the column should not be inferred
+func InferAnnotation(col model.Column) string {
+	// explicit annotations and constraints take precedence
+	if col.Annotation != "" || len(col.Constraints) > 0 || col.IsGenerated {
+		return ""
+	}
+
+	// unique values are type based unless there is a unique generator for the annotation
+	rules := inferenceRules
+	if col.IsUnique {
+		rules = slices.Concat(uniqueInferenceRules, inferenceRules)
+	}
+
+	name := normalizeColumnName(col.Name)
+	for _, rule := range rules {
+		if col.IsUnique && !HasUniqueGenerator(rule.annotation) {
+			continue
+		}
+		if rule.pattern.MatchString(name) && slices.Contains(rule.types, col.Typ) {
+			return rule.annotation
+		}
+	}
+
+	return ""
+}
+
+// InferAnnotations sets the inferred annotation on table columns without one
+func InferAnnotations(table *model.Table) {
+	for index := range table.Columns {
+		if annotation := InferAnnotation(table.Columns[index]); annotation != "" {
+			table.Columns[index].Annotation = annotation
+		}
+	}
+}
+
+// normalizeColumnName converts camelCase and kebab-case names to snake_case
+func normalizeColumnName(name string) string {
+	builder := strings.Builder{}
+	runes := []rune(name)
+	for index, r := range runes {
+		switch {
+		case r == '-' || r == ' ':
+			builder.WriteRune('_')
+		case unicode.IsUpper(r):
+			if index > 0 && runes[index-1] != '_' && !unicode.IsUpper(runes[index-1]) {
+				builder.WriteRune('_')
+			}
+			builder.WriteRune(unicode.ToLower(r))
+		default:
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,65 @@
+package generator
+
+import (
+	"dbaker/pkg/model"
+	"testing"
+)
+
+func TestInferAnnotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   model.Column
+		expected string
+	}{
+		{name: "first name", column: model.Column{Name: "first_name", Typ: model.Varchar}, expected: "firstName"},
+		{name: "camel case", column: model.Column{Name: "lastName", Typ: model.Text}, expected: "lastName"},
+		{name: "prefixed email", column: model.Column{Name: "contact_email", Typ: model.Varchar}, expected: "email"},
+		{name: "timestamp suffix", column: model.Column{Name: "created_at", Typ: model.Timestamp}, expected: "pastDate"},
+		{name: "integer price", column: model.Column{Name: "price", Typ: model.Int}, expected: "intRange(1,1000)"},
+		{name: "float price", column: model.Column{Name: "price", Typ: model.Double}, expected: "price(1,1000)"},
+		{name: "incompatible type", column: model.Column{Name: "email", Typ: model.Int}, expected: ""},
+		{name: "unknown name", column: model.Column{Name: "group_name", Typ: model.Varchar}, expected: ""},
+		{name: "existing annotation", column: model.Column{Name: "email", Typ: model.Varchar, Annotation: "url"}, expected: ""},
+		{name: "no-infer marker", column: model.Column{Name: "email", Typ: model.Varchar, Annotation: NoInferAnnotation}, expected: ""},
+		{name: "unique column", column: model.Column{Name: "city", Typ: model.Varchar, IsUnique: true}, expected: ""},
+		{name: "unique email", column: model.Column{Name: "email", Typ: model.Varchar, IsUnique: true}, expected: "email"},
+		{name: "unique slug", column: model.Column{Name: "post_slug", Typ: model.Text, IsUnique: true}, expected: "slug"},
+		{name: "slug which is not unique", column: model.Column{Name: "slug", Typ: model.Text}, expected: ""},
+		{
+			name:     "constrained column",
+			column:   model.Column{Name: "price", Typ: model.Int, Constraints: []model.Constraint{{Op: model.OpGt, Value: "0"}}},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := InferAnnotation(tt.column)
+			if result != tt.expected {
+				t.Errorf("InferAnnotation(%s %s) = %q; want %q", tt.column.Name, tt.column.Typ, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInferenceRulesAreValidAnnotations(t *testing.T) {
+	for _, rule := range inferenceRules {
+		fn, err := compileAnnotation(rule.annotation)
+		if err != nil {
+			t.Errorf("rule %s: invalid annotation %q: %v", rule.pattern, rule.annotation, err)
+			continue
+		}
+
+		value, err := fn()
+		if err != nil {
+			t.Errorf("rule %s: failed to evaluate %q: %v", rule.pattern, rule.annotation, err)
+			continue
+		}
+
+		for _, typ := range rule.types {
+			if _, err := coerceVal(model.Column{Typ: typ}, value); err != nil {
+				t.Errorf("rule %s: value of %q not compatible with %s: %v", rule.pattern, rule.annotation, typ, err)
+			}
+		}
+	}
+}
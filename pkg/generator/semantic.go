@@ -0,0 +1,231 @@
+package generator
+
+import (
+	"crypto/sha1"
+	"dbaker/pkg/model"
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// uniqueGenerator generates realistic values of annotated unique columns, the index the iteration maps onto
+// is part of each value, which keeps them distinct. Values are derived from the key (seed, table and column)
+// and the index alone, so they are the same on every run.
+type uniqueGenerator struct {
+	types []model.ColumnType
+	// shortest column holding the values of every index
+	minLength uint
+	generate  func(faker *gofakeit.Faker, key uint64, index uint64, maxLength uint) (string, error)
+}
+
+// uniqueGenerators by the lower cased annotation name
+var uniqueGenerators = map[string]uniqueGenerator{
+	"email":    {textTypes, 24, uniqueEmail},
+	"username": {textTypes, 12, uniqueUsername},
+	"slug":     {textTypes, 12, uniqueSlug},
+	"phone":    {textTypes, 10, uniquePhone},
+	"uuidv5":   {append([]model.ColumnType{model.UUID}, textTypes...), 36, uuidV5},
+	"uuidv7":   {append([]model.ColumnType{model.UUID}, textTypes...), 36, uuidV7},
+}
+
+// emailDomains are reserved for documentation, generated emails never reach anyone
+var emailDomains = []string{"example.com", "example.net", "example.org"}
+
+// uuidV7Epoch is the time of the first unique uuid v7, every further index is a millisecond later
+var uuidV7Epoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// uniqueGeneratorOf returns the unique generator of the column annotation
+func uniqueGeneratorOf(col model.Column) (uniqueGenerator, bool) {
+	name, _, _ := strings.Cut(col.Annotation, "(")
+	generator, ok := uniqueGenerators[strings.ToLower(strings.TrimSpace(name))]
+	return generator, ok
+}
+
+// HasUniqueGenerator tells whether unique columns with the annotation get realistic values of their own
+func HasUniqueGenerator(annotation string) bool {
+	_, ok := uniqueGeneratorOf(model.Column{Annotation: annotation})
+	return ok
+}
+
+// uniqueIterPatterns find the index within the values of the unique generators which hold it as a number
+var uniqueIterPatterns = map[string]string{
+	"email":    `\+([0-9]{1,18})@`,
+	"username": `([0-9]{1,18})$`,
+	"slug":     `-([0-9]{1,18})$`,
+}
+
+// UniqueIterPattern returns the regular expression (POSIX) whose first group is the index unique text values
+// of the column were generated from, empty when the values don't hold it (e.g. phone numbers are shuffled)
+func UniqueIterPattern(col model.Column) string {
+	if !containsType(textTypes, col.Typ) {
+		return ""
+	}
+
+	name, _, _ := strings.Cut(col.Annotation, "(")
+	name = strings.ToLower(strings.TrimSpace(name))
+	if _, ok := uniqueGenerators[name]; !ok {
+		// generated unique text starts with the index
+		return `^([0-9]{1,18})`
+	}
+	return uniqueIterPatterns[name]
+}
+
+// IsTimeOrderedUUID reports whether the unique values of the column are uuid v7, whose timestamp is
+// a millisecond per index after the epoch
+func IsTimeOrderedUUID(col model.Column) bool {
+	// unique uuid columns default to uuid v7
+	if col.Typ == model.UUID && !HasUniqueGenerator(col.Annotation) {
+		return true
+	}
+
+	name, _, _ := strings.Cut(col.Annotation, "(")
+	return strings.ToLower(strings.TrimSpace(name)) == "uuidv7"
+}
+
+// prepareUniqueGenerator checks that the column can hold the values of the unique generator
+func prepareUniqueGenerator(col model.Column, generator uniqueGenerator) error {
+	if !containsType(generator.types, col.Typ) {
+		return fmt.Errorf("unique %s values can't be stored in '%s' column", col.Annotation, col.Typ)
+	}
+	if col.Typ != model.UUID && col.MaxLength > 0 && col.MaxLength < generator.minLength {
+		return fmt.Errorf("unique %s values need at least %d characters, the column holds %d", col.Annotation, generator.minLength, col.MaxLength)
+	}
+	return nil
+}
+
+// genSemanticUniqueVal generates the value of the unique generator for the index
+func (g *ValueGenerator) genSemanticUniqueVal(table string, col model.Column, generator uniqueGenerator, index uint64) (any, error) {
+	key := g.valueKey(table, col)
+	faker := gofakeit.NewFaker(rand.NewPCG(key, index), false)
+
+	value, err := generator.generate(faker, key, index, col.MaxLength)
+	if err != nil {
+		return nil, err
+	}
+	if col.MaxLength > 0 && uint(len(value)) > col.MaxLength {
+		return nil, fmt.Errorf("%w: unique %s value '%s' is longer than %d characters", ErrUniqueCapacity, col.Annotation, value, col.MaxLength)
+	}
+	return value, nil
+}
+
+// valueKey keys the values of the column of the table by the seed
+func (g *ValueGenerator) valueKey(table string, col model.Column) uint64 {
+	return mix(g.scrambleKey(table, col), hashString(table))
+}
+
+// uniqueEmail generates first.last+N@domain, the name is shortened to fit the column
+func uniqueEmail(faker *gofakeit.Faker, _ uint64, index uint64, maxLength uint) (string, error) {
+	domain := emailDomains[faker.IntN(len(emailDomains))]
+	suffix := "+" + strconv.FormatUint(index, 10) + "@" + domain
+
+	local := letters(faker.FirstName()) + "." + letters(faker.LastName())
+	if maxLength > 0 && uint(len(local)+len(suffix)) > maxLength {
+		local = strings.TrimRight(local[:max(0, int(maxLength)-len(suffix))], ".")
+	}
+	return local + suffix, nil
+}
+
+// uniqueUsername generates first_lastN, the name is shortened to fit the column
+func uniqueUsername(faker *gofakeit.Faker, _ uint64, index uint64, maxLength uint) (string, error) {
+	digits := strconv.FormatUint(index, 10)
+
+	name := letters(faker.FirstName()) + "_" + letters(faker.LastName())
+	if maxLength > 0 && uint(len(name)+len(digits)) > maxLength {
+		name = strings.TrimRight(name[:max(1, int(maxLength)-len(digits))], "_")
+	}
+	return name + digits, nil
+}
+
+// uniqueSlug generates adjective-noun-N, words are left out to fit the column
+func uniqueSlug(faker *gofakeit.Faker, _ uint64, index uint64, maxLength uint) (string, error) {
+	digits := strconv.FormatUint(index, 10)
+
+	words := []string{letters(faker.Adjective()), letters(faker.Noun())}
+	for len(words) > 1 && maxLength > 0 && uint(len(strings.Join(words, "-"))+1+len(digits)) > maxLength {
+		words = words[1:]
+	}
+	slug := strings.Join(words, "-")
+	if maxLength > 0 && uint(len(slug)+1+len(digits)) > maxLength {
+		slug = slug[:max(1, int(maxLength)-len(digits)-1)]
+	}
+	return slug + "-" + digits, nil
+}
+
+// uniquePhone generates a north american number +1-AAA-LLL-LLLL, the area code and line number are both
+// derived from the index: each area code takes ten million indexes, shuffled within
+func uniquePhone(_ *gofakeit.Faker, key uint64, index uint64, maxLength uint) (string, error) {
+	const lines = 10_000_000
+	area := 200 + (index/lines+key)%800
+	line := permute(key, index%lines, lines)
+
+	if maxLength > 0 && maxLength < 15 {
+		return fmt.Sprintf("%03d%07d", area, line), nil
+	}
+	return fmt.Sprintf("+1-%03d-%03d-%04d", area, line/10_000, line%10_000), nil
+}
+
+// uuidV5 generates the name based (sha-1) uuid of the index within the namespace of the key
+func uuidV5(_ *gofakeit.Faker, key uint64, index uint64, _ uint) (string, error) {
+	var namespace [16]byte
+	binary.BigEndian.PutUint64(namespace[:8], key)
+	binary.BigEndian.PutUint64(namespace[8:], mix(key, 0))
+
+	hash := sha1.New()
+	hash.Write(namespace[:])
+	hash.Write([]byte(strconv.FormatUint(index, 10)))
+
+	var uuid [16]byte
+	copy(uuid[:], hash.Sum(nil))
+	return formatUUID(uuid, 5), nil
+}
+
+// uuidV7 generates a time ordered uuid, the timestamp is a millisecond per index after the epoch, so the
+// uuids are distinct, the random bits are derived from the key and the index
+func uuidV7(_ *gofakeit.Faker, key uint64, index uint64, _ uint) (string, error) {
+	var uuid [16]byte
+	binary.BigEndian.PutUint64(uuid[:8], (uint64(uuidV7Epoch.UnixMilli())+index)<<16)
+	binary.BigEndian.PutUint16(uuid[6:8], uint16(mix(key, index)))
+	binary.BigEndian.PutUint64(uuid[8:], mix(key+1, index))
+	return formatUUID(uuid, 7), nil
+}
+
+// formatUUID sets the version and the variant of the uuid
+func formatUUID(uuid [16]byte, version byte) string {
+	uuid[6] = uuid[6]&0x0f | version<<4
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
+
+// letters lower cases the text and drops everything but ascii letters (e.g. O'Keefe)
+func letters(text string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if r < 'a' || r > 'z' {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+func hashString(text string) uint64 {
+	var hash uint64 = 14695981039346656037
+	for index := 0; index < len(text); index++ {
+		hash = (hash ^ uint64(text[index])) * 1099511628211
+	}
+	return hash
+}
+
+func containsType(types []model.ColumnType, typ model.ColumnType) bool {
+	for _, candidate := range types {
+		if candidate == typ {
+			return true
+		}
+	}
+	return false
+}
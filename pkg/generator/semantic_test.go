@@ -0,0 +1,135 @@
+package generator
+
+import (
+	"dbaker/pkg/config"
+	"dbaker/pkg/model"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGenUniqueValSemantic(t *testing.T) {
+	testCases := []struct {
+		column  model.Column
+		pattern string
+	}{
+		{column: model.Column{Name: "email", Typ: model.Varchar, MaxLength: 30, Annotation: "email"}, pattern: `^[a-z]+(\.[a-z]*)?\+\d+@example\.(com|net|org)$`},
+		{column: model.Column{Name: "login", Typ: model.Text, Annotation: "username"}, pattern: `^[a-z]+_[a-z]+\d+$`},
+		{column: model.Column{Name: "slug", Typ: model.Varchar, MaxLength: 12, Annotation: "slug"}, pattern: `^[a-z-]+-\d+$`},
+		{column: model.Column{Name: "phone", Typ: model.Varchar, MaxLength: 20, Annotation: "phone"}, pattern: `^\+1-[2-9]\d{2}-\d{3}-\d{4}$`},
+		{column: model.Column{Name: "mobile", Typ: model.Char, MaxLength: 10, Annotation: "phone"}, pattern: `^[2-9]\d{9}$`},
+		{column: model.Column{Name: "ref", Typ: model.UUID, Annotation: "uuidv5"}, pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{column: model.Column{Name: "id", Typ: model.UUID}, pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.column.Name, func(t *testing.T) {
+			tc.column.IsUnique = true
+			gen := NewValueGenerator(config.Config{Scramble: true, Seed: 7})
+			pattern := regexp.MustCompile(tc.pattern)
+
+			seen := make(map[any]bool)
+			for iter := range uint32(2000) {
+				value, err := gen.GenUniqueVal("public.users", tc.column, iter*997)
+				if err != nil {
+					t.Fatalf("GenUniqueVal(%d) unexpected error: %v", iter, err)
+				}
+				text := value.(string)
+				if !pattern.MatchString(text) || (tc.column.MaxLength > 0 && uint(len(text)) > tc.column.MaxLength) {
+					t.Fatalf("GenUniqueVal(%d) = %q; want matching %s", iter, text, tc.pattern)
+				}
+				if seen[text] {
+					t.Fatalf("GenUniqueVal(%d) = %q repeats", iter, text)
+				}
+				seen[text] = true
+			}
+		})
+	}
+}
+
+func TestGenUniqueValSemanticIsDeterministic(t *testing.T) {
+	col := model.Column{Name: "id", Typ: model.UUID, IsUnique: true, Annotation: "uuidv5"}
+
+	value, _ := NewValueGenerator(config.Config{Seed: 1}).GenUniqueVal("public.users", col, 5)
+	repeated, _ := NewValueGenerator(config.Config{Seed: 1}).GenUniqueVal("public.users", col, 5)
+	otherTable, _ := NewValueGenerator(config.Config{Seed: 1}).GenUniqueVal("public.orders", col, 5)
+	otherSeed, _ := NewValueGenerator(config.Config{Seed: 2}).GenUniqueVal("public.users", col, 5)
+
+	if value != repeated {
+		t.Errorf("GenUniqueVal() = %v, then %v; want the same value for the same seed and table", value, repeated)
+	}
+	if value == otherTable || value == otherSeed {
+		t.Errorf("GenUniqueVal() = %v for another table or seed; want a different value", value)
+	}
+}
+
+func TestNextUniqueIterInvertsSemanticValues(t *testing.T) {
+	gen := NewValueGenerator(config.Config{Seed: 3})
+	columns := []model.Column{
+		{Name: "email", Typ: model.Varchar, IsUnique: true, Annotation: "email"},
+		{Name: "login", Typ: model.Text, IsUnique: true, Annotation: "username"},
+		{Name: "slug", Typ: model.Text, IsUnique: true, Annotation: "slug"},
+		{Name: "id", Typ: model.UUID, IsUnique: true},
+	}
+
+	for _, col := range columns {
+		t.Run(col.Name, func(t *testing.T) {
+			value, err := gen.GenUniqueVal("public.users", col, 41)
+			if err != nil {
+				t.Fatalf("GenUniqueVal() unexpected error: %v", err)
+			}
+
+			// the database hands back the number of the pattern, or the timestamp of uuid v7
+			var number int64
+			if IsTimeOrderedUUID(col) {
+				number, err = strconv.ParseInt(strings.ReplaceAll(value.(string), "-", "")[:12], 16, 64)
+			} else {
+				match := regexp.MustCompile(UniqueIterPattern(col)).FindStringSubmatch(value.(string))
+				if match == nil {
+					t.Fatalf("UniqueIterPattern() doesn't match %q", value)
+				}
+				number, err = strconv.ParseInt(match[1], 10, 64)
+			}
+			if err != nil {
+				t.Fatalf("failed to parse %q: %v", value, err)
+			}
+
+			next, err := NextUniqueIter(col, number)
+			if err != nil || next != 42 {
+				t.Errorf("NextUniqueIter(%d) = %d, %v; want 42", number, next, err)
+			}
+		})
+	}
+
+	if pattern := UniqueIterPattern(model.Column{Typ: model.Varchar, Annotation: "phone"}); pattern != "" {
+		t.Errorf("UniqueIterPattern() = %q for shuffled phone numbers; want none", pattern)
+	}
+}
+
+func TestPrepareValidatesUniqueGenerators(t *testing.T) {
+	tests := []struct {
+		name    string
+		column  model.Column
+		wantErr string
+	}{
+		{name: "email", column: model.Column{Typ: model.Varchar, MaxLength: 64, Annotation: "email"}},
+		{name: "uuid in text", column: model.Column{Typ: model.Text, Annotation: "uuidv7"}},
+		{name: "short column", column: model.Column{Typ: model.Varchar, MaxLength: 16, Annotation: "email"}, wantErr: "at least 24 characters"},
+		{name: "wrong type", column: model.Column{Typ: model.Int, Annotation: "phone"}, wantErr: "can't be stored"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.column.Name, tt.column.IsUnique = "a", true
+			tables := []model.Table{{Name: "t", Schema: "public", Columns: []model.Column{tt.column}}}
+			err := NewValueGenerator(config.Config{}).Prepare(tables)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Prepare() unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Prepare() error = %v; want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
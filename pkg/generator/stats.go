@@ -0,0 +1,270 @@
+package generator
+
+import (
+	"dbaker/pkg/model"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// profiled values are rounded to steps of about a hundredth of the column range
+const statsGridSize = 100
+
+// steps temporal values are rounded to, in days for dates and seconds otherwise
+var temporalSteps = map[model.ColumnType][]float64{
+	model.Date:        {1, 7, 30, 365},
+	model.Time:        {1, 60, 3600},
+	model.Timestamp:   {1, 60, 3600, secondsPerDay, 7 * secondsPerDay, 30 * secondsPerDay, 365 * secondsPerDay},
+	model.TimestampTZ: {1, 60, 3600, secondsPerDay, 7 * secondsPerDay, 30 * secondsPerDay, 365 * secondsPerDay},
+}
+
+// CoarsenStats rounds the profiled values of the table columns (min/max, histogram bounds and most common values)
+// to a grid of about a hundredth of the column range, so that the recipe holds no actual values of the data.
+// Values which are not finite numbers or valid dates drop the part of the stats they belong to.
+func CoarsenStats(table *model.Table) {
+	for index := range table.Columns {
+		if stats := table.Columns[index].Stats; stats != nil {
+			coarsenStats(table.Columns[index].Typ, stats)
+		}
+	}
+}
+
+func coarsenStats(typ model.ColumnType, stats *model.ColumnStats) {
+	if _, _, ok := typeRange(typ); !ok {
+		stats.Min, stats.Max, stats.Histogram, stats.MostCommon = "", "", nil, nil
+		return
+	}
+
+	// the grid spans all profiled values, values which are not finite drop the part they belong to
+	histogram, histogramOk := statsOrdinals(typ, stats.Histogram)
+	common, commonOk := statsOrdinals(typ, categoryValues(stats.MostCommon))
+	low, lowOk := statsOrdinal(typ, stats.Min)
+	high, highOk := statsOrdinal(typ, stats.Max)
+	ordinals := slices.Concat(histogram, common)
+	if lowOk {
+		ordinals = append(ordinals, low)
+	}
+	if highOk {
+		ordinals = append(ordinals, high)
+	}
+	if len(ordinals) == 0 {
+		stats.Min, stats.Max, stats.Histogram, stats.MostCommon = "", "", nil, nil
+		return
+	}
+	step := gridStep(typ, slices.Min(ordinals), slices.Max(ordinals))
+
+	stats.Min, stats.Max = "", ""
+	if lowOk {
+		stats.Min = gridValue(typ, math.Floor(low/step)*step, step)
+	}
+	if highOk {
+		stats.Max = gridValue(typ, math.Ceil(high/step)*step, step)
+	}
+
+	stats.Histogram = nil
+	if histogramOk {
+		for _, ordinal := range histogram {
+			stats.Histogram = append(stats.Histogram, gridValue(typ, math.Round(ordinal/step)*step, step))
+		}
+	}
+
+	// values rounded to the same one are merged
+	var merged []model.Category
+	if commonOk {
+		for index, ordinal := range common {
+			value := gridValue(typ, math.Round(ordinal/step)*step, step)
+			if existing := slices.IndexFunc(merged, func(c model.Category) bool { return c.Value == value }); existing >= 0 {
+				merged[existing].Weight += stats.MostCommon[index].Weight
+				continue
+			}
+			merged = append(merged, model.Category{Value: value, Weight: stats.MostCommon[index].Weight})
+		}
+	}
+	stats.MostCommon = merged
+}
+
+// gridStep returns the step profiled values are rounded to: a power of ten for numbers
+// (whole numbers for integers), a calendar unit for temporal values
+func gridStep(typ model.ColumnType, low float64, high float64) float64 {
+	target := (high - low) / statsGridSize
+	if steps, ok := temporalSteps[typ]; ok {
+		if target <= 0 {
+			// a single value, rounded by the largest unit
+			return steps[len(steps)-1]
+		}
+		step := steps[0]
+		for _, unit := range steps {
+			if unit <= target {
+				step = unit
+			}
+		}
+		return step
+	}
+
+	if target <= 0 {
+		target = max(math.Abs(low), math.Abs(high)) / statsGridSize
+	}
+	step := 1.0
+	if target > 0 {
+		step = math.Pow(10, math.Floor(math.Log10(target)))
+	}
+	if !isFloat(typ) {
+		step = max(step, 1)
+	}
+	return step
+}
+
+// gridValue formats the ordinal of a grid value, numbers with no more decimals than the step has
+func gridValue(typ model.ColumnType, ordinal float64, step float64) string {
+	if typ == model.Time {
+		// rounding up must not wrap around midnight
+		ordinal = min(ordinal, secondsPerDay-1)
+	}
+	if isTemporal(typ) {
+		return fromOrdinal(typ, ordinal).(string)
+	}
+
+	decimals := max(0, int(-math.Floor(math.Log10(step))))
+	return strconv.FormatFloat(ordinal, 'f', decimals, 64)
+}
+
+// statsOrdinals converts profiled values into their ordinals, fails when any of them is not valid
+func statsOrdinals(typ model.ColumnType, values []string) ([]float64, bool) {
+	ordinals := make([]float64, 0, len(values))
+	for _, value := range values {
+		ordinal, ok := statsOrdinal(typ, value)
+		if !ok {
+			return nil, false
+		}
+		ordinals = append(ordinals, ordinal)
+	}
+	return ordinals, true
+}
+
+func categoryValues(categories []model.Category) []string {
+	values := make([]string, len(categories))
+	for index, category := range categories {
+		values[index] = category.Value
+	}
+	return values
+}
+
+// statsOrdinal converts a profiled value into its ordinal, values which are not finite are rejected
+func statsOrdinal(typ model.ColumnType, value string) (float64, bool) {
+	if value == "" {
+		return 0, false
+	}
+	ordinal, err := toOrdinal(typ, value)
+	if err != nil || math.IsNaN(ordinal) || math.IsInf(ordinal, 0) {
+		return 0, false
+	}
+	return ordinal, true
+}
+
+// ApplyStats derives generator settings from profiled column statistics, so that generated
+// data has a similar shape: null ratios of nullable columns and distributions of numeric
+// and temporal columns (from histograms and most common values, or min/max when there are none)
+func ApplyStats(table *model.Table) {
+	for index := range table.Columns {
+		col := &table.Columns[index]
+		if col.Stats == nil || col.IsGenerated {
+			continue
+		}
+
+		if col.IsNullable && col.Stats.NullFraction > 0 {
+			nullRatio := col.Stats.NullFraction
+			col.NullRatio = &nullRatio
+		}
+
+		// unique values are derived from the iteration
+		if col.IsUnique {
+			continue
+		}
+
+		if dist := statsDistribution(*col); dist != nil {
+			col.Distribution = dist
+			// measured data beats name based guesses
+			col.Annotation = ""
+		}
+	}
+}
+
+func statsDistribution(col model.Column) *model.Distribution {
+	if _, _, ok := typeRange(col.Typ); !ok {
+		return nil
+	}
+
+	var bounds []model.Param
+	for _, bound := range col.Stats.Histogram {
+		param, ok := statsParam(col.Typ, bound)
+		if !ok {
+			return nil
+		}
+		bounds = append(bounds, *param)
+	}
+
+	common, ok := mostCommonValues(col)
+	if !ok {
+		return nil
+	}
+
+	// pg_stats leaves out the histogram when the most common values are all there is
+	if len(bounds) >= 2 {
+		return &model.Distribution{Kind: model.Histogram, Bounds: bounds, Categories: common}
+	}
+	if len(common) > 0 {
+		return &model.Distribution{Kind: model.Categorical, Categories: common}
+	}
+
+	low, lowOk := statsParam(col.Typ, col.Stats.Min)
+	high, highOk := statsParam(col.Typ, col.Stats.Max)
+	if !lowOk || !highOk {
+		return nil
+	}
+
+	return &model.Distribution{Kind: model.Uniform, Min: low, Max: high}
+}
+
+// mostCommonValues returns the most common values weighted by their frequency among the values which are not NULL
+func mostCommonValues(col model.Column) ([]model.Category, bool) {
+	values := 1 - col.Stats.NullFraction
+	var common []model.Category
+	for _, category := range col.Stats.MostCommon {
+		if _, ok := statsParam(col.Typ, category.Value); !ok || values <= 0 {
+			return nil, false
+		}
+		common = append(common, model.Category{Value: category.Value, Weight: category.Weight / values})
+	}
+
+	// rounded frequencies may add up to a bit more than all values
+	var total float64
+	for _, category := range common {
+		total += category.Weight
+	}
+	for index := range common {
+		common[index].Weight /= max(total, 1)
+	}
+
+	return common, true
+}
+
+// statsParam converts a value in its text representation to a distribution parameter
+func statsParam(typ model.ColumnType, value string) (*model.Param, bool) {
+	if value == "" {
+		return nil, false
+	}
+
+	if isTemporal(typ) {
+		if _, err := parseTemporal(typ, value); err != nil {
+			return nil, false
+		}
+		return model.TextParam(value), true
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return nil, false
+	}
+
+	return model.NumberParam(number), true
+}
@@ -0,0 +1,164 @@
+package generator
+
+import (
+	"dbaker/pkg/model"
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestApplyStats(t *testing.T) {
+	table := model.Table{
+		Columns: []model.Column{
+			{Name: "id", Typ: model.Int, IsUnique: true, Stats: &model.ColumnStats{Min: "1", Max: "100"}},
+			{Name: "price", Typ: model.Int, IsNullable: true, Annotation: "intRange(1,1000)", Stats: &model.ColumnStats{NullFraction: 0.25, Histogram: []string{"1", "10", "90"}}},
+			{Name: "created_at", Typ: model.Timestamp, Stats: &model.ColumnStats{Min: "2024-01-01 10:00:00", Max: "2024-06-01 10:00:00"}},
+			{Name: "email", Typ: model.Varchar, Annotation: "email", Stats: &model.ColumnStats{NullFraction: 0.5}},
+		},
+	}
+
+	ApplyStats(&table)
+
+	id, price, createdAt, email := table.Columns[0], table.Columns[1], table.Columns[2], table.Columns[3]
+	if id.Distribution != nil {
+		t.Errorf("unique column got distribution %+v", id.Distribution)
+	}
+	if price.NullRatio == nil || *price.NullRatio != 0.25 {
+		t.Errorf("price null ratio = %v; want 0.25", price.NullRatio)
+	}
+	if price.Distribution == nil || price.Distribution.Kind != model.Histogram || len(price.Distribution.Bounds) != 3 {
+		t.Errorf("price distribution = %+v; want histogram with 3 bounds", price.Distribution)
+	}
+	if price.Annotation != "" {
+		t.Errorf("price annotation = %q; want it replaced by the distribution", price.Annotation)
+	}
+	if createdAt.Distribution == nil || createdAt.Distribution.Kind != model.Uniform {
+		t.Errorf("created_at distribution = %+v; want uniform", createdAt.Distribution)
+	}
+	if email.NullRatio != nil || email.Distribution != nil || email.Annotation != "email" {
+		t.Errorf("email = %+v; want it untouched", email)
+	}
+
+	for _, col := range table.Columns {
+		if col.Distribution == nil {
+			continue
+		}
+		if _, err := compileDistribution(col); err != nil {
+			t.Errorf("column %s: derived distribution is invalid: %v", col.Name, err)
+		}
+	}
+}
+
+func TestApplyStatsKeepsMostCommonValues(t *testing.T) {
+	table := model.Table{
+		Columns: []model.Column{
+			// skewed column: 0 in 60% of the non null rows, the histogram covers the rest
+			{Name: "discount", Typ: model.Int, IsNullable: true, Stats: &model.ColumnStats{
+				NullFraction: 0.5, Histogram: []string{"5", "10", "50"}, MostCommon: []model.Category{{Value: "0", Weight: 0.3}},
+			}},
+			// pg_stats has no histogram when the most common values are all there is
+			{Name: "rating", Typ: model.SmallInt, Stats: &model.ColumnStats{
+				MostCommon: []model.Category{{Value: "5", Weight: 0.7}, {Value: "1", Weight: 0.3}},
+			}},
+		},
+	}
+
+	ApplyStats(&table)
+
+	discount, rating := table.Columns[0], table.Columns[1]
+	if dist := discount.Distribution; dist == nil || dist.Kind != model.Histogram || len(dist.Categories) != 1 || dist.Categories[0].Weight != 0.6 {
+		t.Errorf("discount distribution = %+v; want histogram with 0 weighted 0.6", dist)
+	}
+	if dist := rating.Distribution; dist == nil || dist.Kind != model.Categorical || len(dist.Categories) != 2 {
+		t.Errorf("rating distribution = %+v; want categorical of the most common values", dist)
+	}
+
+	// the most common value is drawn about as often as it was profiled
+	fn, err := compileDistribution(discount)
+	if err != nil {
+		t.Fatalf("compileDistribution() unexpected error: %v", err)
+	}
+	zeros := 0
+	for range 10000 {
+		value, err := fn()
+		if err != nil {
+			t.Fatalf("distribution unexpected error: %v", err)
+		}
+		if value == int64(0) {
+			zeros++
+		}
+	}
+	if zeros < 5500 || zeros > 6500 {
+		t.Errorf("distribution drew 0 %d times of 10000; want about 6000", zeros)
+	}
+}
+
+func TestCoarsenStats(t *testing.T) {
+	table := model.Table{
+		Columns: []model.Column{
+			{Name: "salary", Typ: model.Int, Stats: &model.ColumnStats{
+				Min: "20113", Max: "198765", Histogram: []string{"20113", "73412", "198765"},
+				MostCommon: []model.Category{{Value: "50250", Weight: 0.1}, {Value: "49980", Weight: 0.05}},
+			}},
+			{Name: "rating", Typ: model.SmallInt, Stats: &model.ColumnStats{MostCommon: []model.Category{{Value: "5", Weight: 0.7}, {Value: "1", Weight: 0.3}}}},
+			{Name: "ratio", Typ: model.Double, Stats: &model.ColumnStats{Min: "0.1234", Max: "0.9876", Histogram: []string{"0.1234", "NaN", "0.9876"}}},
+			{Name: "birth_date", Typ: model.Date, Stats: &model.ColumnStats{Min: "1950-03-17", Max: "2005-11-02", Histogram: []string{"1950-03-17", "1978-06-21", "2005-11-02"}}},
+			{Name: "email", Typ: model.Varchar, Stats: &model.ColumnStats{Min: "a@example.com", NullFraction: 0.1}},
+		},
+	}
+
+	CoarsenStats(&table)
+
+	salary := table.Columns[0].Stats
+	if salary.Min != "20000" || salary.Max != "199000" || !slices.Equal(salary.Histogram, []string{"20000", "73000", "199000"}) {
+		t.Errorf("salary stats = %+v; want rounded to thousands", salary)
+	}
+	if !reflect.DeepEqual(salary.MostCommon, []model.Category{{Value: "50000", Weight: 0.15000000000000002}}) {
+		t.Errorf("salary most common = %+v; want values rounded to the same one merged", salary.MostCommon)
+	}
+	if rating := table.Columns[1].Stats; !reflect.DeepEqual(rating.MostCommon, []model.Category{{Value: "5", Weight: 0.7}, {Value: "1", Weight: 0.3}}) {
+		t.Errorf("rating most common = %+v; want whole numbers kept", rating.MostCommon)
+	}
+	if ratio := table.Columns[2].Stats; ratio.Histogram != nil || ratio.Min != "0.123" || ratio.Max != "0.988" {
+		t.Errorf("ratio stats = %+v; want the histogram with NaN dropped and min/max rounded", ratio)
+	}
+
+	birthDate := table.Columns[3].Stats
+	for _, value := range append([]string{birthDate.Min, birthDate.Max}, birthDate.Histogram...) {
+		ordinal, err := toOrdinal(model.Date, value)
+		if err != nil || math.Mod(ordinal, 30) != 0 {
+			t.Errorf("birth date value %s; want a date rounded to 30 days", value)
+		}
+	}
+	if slices.Contains(birthDate.Histogram, "1978-06-21") {
+		t.Errorf("birth date histogram = %v; want no profiled value", birthDate.Histogram)
+	}
+
+	if email := table.Columns[4].Stats; email.Min != "" || email.NullFraction != 0.1 {
+		t.Errorf("email stats = %+v; want values dropped and counts kept", email)
+	}
+}
+
+func TestApplyStatsRejectsNonFiniteValues(t *testing.T) {
+	table := model.Table{
+		Columns: []model.Column{
+			{Name: "ratio", Typ: model.Double, Stats: &model.ColumnStats{Histogram: []string{"0", "1", "Infinity"}}},
+			{Name: "score", Typ: model.Real, Stats: &model.ColumnStats{Min: "NaN", Max: "1"}},
+		},
+	}
+
+	ApplyStats(&table)
+
+	for _, col := range table.Columns {
+		if col.Distribution != nil {
+			t.Errorf("column %s distribution = %+v; want none from non-finite values", col.Name, col.Distribution)
+		}
+	}
+
+	column := model.Column{Typ: model.Double, Distribution: &model.Distribution{Kind: model.Uniform, Min: model.NumberParam(0), Max: model.NumberParam(math.Inf(1))}}
+	if _, err := compileDistribution(column); !errors.Is(err, ErrInvalidDistribution) {
+		t.Errorf("compileDistribution() error = %v; want %v", err, ErrInvalidDistribution)
+	}
+}
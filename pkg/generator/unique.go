@@ -0,0 +1,286 @@
+package generator
+
+import (
+	"dbaker/pkg/model"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+var (
+	ErrUniqueCapacity = errors.New("not enough distinct values")
+)
+
+const (
+	// iterations are uint32, columns holding this many values hold the values of all of them
+	uniqueIterations = 1 << 32
+	// letters following the iteration of unique text in columns without a maximum length
+	uniqueTextPadding = 8
+)
+
+// unique dates and timestamps count from the base, dates end at the last 4 digit year
+var (
+	uniqueBase    = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	uniqueLastDay = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// GenUniqueVal maps the iteration onto a distinct value of the column type: the iteration picks the index
+// of the value within the values the column can hold, scrambled (a keyed permutation) when enabled.
+// Iterations beyond the capacity of the column are reported rather than repeated or overflowing.
+// Semantic annotations (e.g. email) and uuids derive realistic values from the seed, the table and the index.
+func (g *ValueGenerator) GenUniqueVal(table string, col model.Column, iter uint32) (any, error) {
+	index, err := g.uniqueIndex(table, col, iter)
+	if err != nil {
+		return nil, err
+	}
+
+	if generator, ok := uniqueGeneratorOf(col); ok {
+		return g.genSemanticUniqueVal(table, col, generator, index)
+	}
+
+	switch col.Typ {
+	case model.SmallInt, model.Int, model.BigInt:
+		low, _ := uniqueIntRange(col)
+		return low + int64(index), nil
+	case model.Real:
+		return float32(index), nil
+	case model.Double:
+		return float64(index), nil
+
+	case model.Char, model.Varchar:
+		// letters after the digits keep the values distinct
+		digits := strconv.FormatUint(index, 10)
+		padding := uint(uniqueTextPadding)
+		if col.MaxLength > 0 {
+			padding = col.MaxLength - uint(len(digits))
+		}
+		// gofakeit generates a letter at least
+		if padding == 0 {
+			return digits, nil
+		}
+		return digits + gofakeit.LetterN(padding), nil
+	case model.Text:
+		return fmt.Sprintf("%d%s", index, gofakeit.Sentence(rand.IntN(10-1)+1)), nil
+
+	case model.UUID:
+		return uuidV7(nil, g.valueKey(table, col), index, 0)
+	case model.Boolean:
+		return index == 0, nil
+
+	case model.Date:
+		return uniqueBase.AddDate(0, 0, int(index)).Format("2006-01-02"), nil
+	case model.Time:
+		return uniqueBase.Add(time.Duration(index) * time.Second).Format("15:04:05"), nil
+	case model.Timestamp, model.TimestampTZ:
+		return uniqueBase.Add(time.Duration(index) * time.Second).Format(time.RFC3339), nil
+
+	default:
+		return nil, ErrColumnTypeNotSupported
+	}
+}
+
+// uniqueIndex returns the index of the value generated on the iteration
+func (g *ValueGenerator) uniqueIndex(table string, col model.Column, iter uint32) (uint64, error) {
+	capacity, bounded := UniqueCapacity(col)
+	if bounded && uint64(iter) >= capacity {
+		return 0, fmt.Errorf("%w: unique column '%s' holds %d values, iteration %d is beyond them", ErrUniqueCapacity, col.Name, capacity, iter)
+	}
+	if !g.scramble {
+		return uint64(iter), nil
+	}
+
+	domain := uint64(uniqueIterations)
+	if bounded {
+		domain = capacity
+	}
+	return permute(g.scrambleKey(table, col), uint64(iter), domain), nil
+}
+
+// scrambleKey keys the permutation by the seed, the table and the column, so that columns
+// (e.g. the id columns of all tables) are scrambled differently
+func (g *ValueGenerator) scrambleKey(table string, col model.Column) uint64 {
+	hash := fnv.New64a()
+	binary.Write(hash, binary.BigEndian, g.seed)
+	hash.Write([]byte(table))
+	// separates the names, so that table a.b with column c differs from table a with column b.c
+	hash.Write([]byte{0})
+	hash.Write([]byte(col.Name))
+	return hash.Sum64()
+}
+
+// UniqueCapacity returns the number of distinct values GenUniqueVal generates for the column,
+// false for columns which hold the values of all iterations
+func UniqueCapacity(col model.Column) (uint64, bool) {
+	// semantic values hold the index in full
+	if _, ok := uniqueGeneratorOf(col); ok {
+		return 0, false
+	}
+
+	var capacity uint64
+	switch col.Typ {
+	case model.SmallInt, model.Int, model.BigInt:
+		low, high := uniqueIntRange(col)
+		if high < low {
+			return 0, true
+		}
+		if float64(high)-float64(low) >= uniqueIterations {
+			return 0, false
+		}
+		capacity = uint64(high-low) + 1
+	case model.Real:
+		// integers beyond 2^24 are rounded
+		capacity = 1 << 24
+
+	case model.Char, model.Varchar:
+		// the index is written out in full
+		if col.MaxLength == 0 || col.MaxLength >= 10 {
+			return 0, false
+		}
+		capacity = 1
+		for range col.MaxLength {
+			capacity *= 10
+		}
+
+	case model.Boolean:
+		capacity = 2
+	case model.Date:
+		capacity = uint64((uniqueLastDay.Unix()-uniqueBase.Unix())/secondsPerDay) + 1
+	case model.Time:
+		capacity = secondsPerDay
+
+	default:
+		return 0, false
+	}
+
+	return capacity, capacity < uniqueIterations
+}
+
+// uniqueIntRange returns the range of integers unique values are taken from, starting at the lower bound
+// of the column constraints (e.g. id > 0) or else at 0
+func uniqueIntRange(col model.Column) (int64, int64) {
+	low, _ := uniqueLowerBound(col)
+
+	high := int64(math.MaxInt64)
+	switch col.Typ {
+	case model.SmallInt:
+		high = math.MaxInt16
+	case model.Int:
+		high = math.MaxInt32
+	}
+
+	for _, constraint := range col.Constraints {
+		if constraint.Column != "" || constraint.OnLength {
+			continue
+		}
+		value, err := strconv.ParseInt(constraint.Value, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch constraint.Op {
+		case model.OpLt:
+			high = min(high, value-1)
+		case model.OpLte:
+			high = min(high, value)
+		}
+	}
+
+	return low, high
+}
+
+// permute is a keyed bijection of [0, domain): a balanced feistel network over the smallest even number
+// of bits holding the domain, indexes falling outside of the domain are walked on until they are within
+func permute(key uint64, index uint64, domain uint64) uint64 {
+	if domain <= 1 {
+		return index
+	}
+
+	bitCount := bits.Len64(domain - 1)
+	bitCount += bitCount % 2
+	half := uint(bitCount / 2)
+
+	for {
+		index = feistel(key, index, half)
+		if index < domain {
+			return index
+		}
+	}
+}
+
+// feistel is a balanced four round feistel network over values of twice the half bits
+func feistel(key uint64, index uint64, half uint) uint64 {
+	mask := uint64(1)<<half - 1
+	left, right := index>>half, index&mask
+	for round := range uint64(4) {
+		left, right = right, left^(mix(key+round, right)&mask)
+	}
+
+	return left<<half | right
+}
+
+// Permute is the keyed bijection of [0, domain) the unique values are scrambled by, domain 0 stands for
+// all uint64 values. Masks keep distinct values distinct by it.
+func Permute(key uint64, index uint64, domain uint64) uint64 {
+	if domain == 0 {
+		return feistel(key, index, 32)
+	}
+	return permute(key, index, domain)
+}
+
+// mix is the splitmix64 finalizer of the value keyed by the key
+func mix(key uint64, value uint64) uint64 {
+	z := value ^ key
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
+
+// NextUniqueIter returns the iteration following the one the unique value was generated on, the inverse of
+// GenUniqueVal. Text values are given by their number (see UniqueIterPattern), uuid v7 values by their timestamp.
+// Values which were not generated by dbaker may result in a higher iteration than needed, never a lower one.
+// Scrambled values can't be inverted.
+func NextUniqueIter(col model.Column, value any) (uint32, error) {
+	base := uniqueBase
+
+	var next float64
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		// uuid v7 values are given by their timestamp
+		low, _ := uniqueLowerBound(col)
+		if IsTimeOrderedUUID(col) {
+			low = uuidV7Epoch.UnixMilli()
+		}
+		next = float64(v-low) + 1
+	case float32:
+		next = math.Floor(float64(v)) + 1
+	case float64:
+		next = math.Floor(v) + 1
+	case time.Time:
+		// durations saturate at about 292 years, seconds since the epoch do not
+		seconds := float64(v.Unix() - base.Unix())
+		switch col.Typ {
+		case model.Date:
+			next = math.Floor(seconds/secondsPerDay) + 1
+		case model.Timestamp, model.TimestampTZ:
+			next = seconds + 1
+		default:
+			return 0, ErrColumnTypeNotSupported
+		}
+	default:
+		return 0, fmt.Errorf("%w: %T", ErrColumnTypeNotSupported, value)
+	}
+
+	if next > math.MaxUint32 {
+		return 0, fmt.Errorf("unique value %v of column '%s' is beyond the last iteration", value, col.Name)
+	}
+	return uint32(max(next, 0)), nil
+}
@@ -0,0 +1,114 @@
+package generator
+
+import (
+	"dbaker/pkg/config"
+	"dbaker/pkg/model"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPermuteIsBijection(t *testing.T) {
+	for _, domain := range []uint64{1, 2, 3, 7, 100, 1000, 4096, 5000} {
+		t.Run(fmt.Sprint(domain), func(t *testing.T) {
+			seen := make(map[uint64]bool, domain)
+			for index := range domain {
+				permuted := permute(42, index, domain)
+				if permuted >= domain || seen[permuted] {
+					t.Fatalf("permute(%d) = %d; want a distinct index below %d", index, permuted, domain)
+				}
+				seen[permuted] = true
+			}
+		})
+	}
+}
+
+func TestGenUniqueValFillsCapacity(t *testing.T) {
+	columns := []model.Column{
+		{Name: "code", Typ: model.Varchar, MaxLength: 2, IsUnique: true},
+		{Name: "flag", Typ: model.Boolean, IsUnique: true},
+		{Name: "level", Typ: model.SmallInt, IsUnique: true, Constraints: []model.Constraint{{Op: model.OpGte, Value: "1"}, {Op: model.OpLt, Value: "300"}}},
+	}
+
+	for _, scramble := range []bool{false, true} {
+		gen := NewValueGenerator(config.Config{Scramble: scramble, Seed: 7})
+		for _, col := range columns {
+			t.Run(fmt.Sprintf("%s scramble %v", col.Name, scramble), func(t *testing.T) {
+				capacity, bounded := UniqueCapacity(col)
+				if !bounded {
+					t.Fatalf("UniqueCapacity() is unbounded")
+				}
+
+				seen := make(map[string]bool)
+				for iter := range uint32(capacity) {
+					value, err := gen.GenUniqueVal("public.t", col, iter)
+					if err != nil {
+						t.Fatalf("GenUniqueVal(%d) unexpected error: %v", iter, err)
+					}
+					if text, ok := value.(string); ok && uint(len(text)) != col.MaxLength {
+						t.Fatalf("GenUniqueVal(%d) = %q; want length %d", iter, text, col.MaxLength)
+					}
+					if number, ok := value.(int64); ok && (number < 1 || number >= 300) {
+						t.Fatalf("GenUniqueVal(%d) = %d; want within [1, 300)", iter, number)
+					}
+
+					if seen[fmt.Sprint(value)] {
+						t.Fatalf("GenUniqueVal(%d) = %v repeats", iter, value)
+					}
+					seen[fmt.Sprint(value)] = true
+				}
+
+				_, err := gen.GenUniqueVal("public.t", col, uint32(capacity))
+				if !errors.Is(err, ErrUniqueCapacity) {
+					t.Errorf("GenUniqueVal(%d) error = %v; want ErrUniqueCapacity", capacity, err)
+				}
+			})
+		}
+	}
+}
+
+func TestGenUniqueValScrambles(t *testing.T) {
+	col := model.Column{Name: "id", Typ: model.Int, IsUnique: true}
+	sequential := NewValueGenerator(config.Config{})
+	scrambled := NewValueGenerator(config.Config{Scramble: true, Seed: 1})
+	again := NewValueGenerator(config.Config{Scramble: true, Seed: 1})
+	reseeded := NewValueGenerator(config.Config{Scramble: true, Seed: 2})
+
+	var moved, differs, differsByTable int
+	for iter := range uint32(100) {
+		want, _ := sequential.GenUniqueVal("public.t", col, iter)
+		value, _ := scrambled.GenUniqueVal("public.t", col, iter)
+		repeated, _ := again.GenUniqueVal("public.t", col, iter)
+		other, _ := reseeded.GenUniqueVal("public.t", col, iter)
+		otherTable, _ := scrambled.GenUniqueVal("public.u", col, iter)
+
+		if value != repeated {
+			t.Fatalf("GenUniqueVal(%d) = %v, then %v; want the same value for the same seed", iter, value, repeated)
+		}
+		if value != want {
+			moved++
+		}
+		if value != other {
+			differs++
+		}
+		if value != otherTable {
+			differsByTable++
+		}
+	}
+
+	if moved < 90 || differs < 90 || differsByTable < 90 {
+		t.Errorf("scrambled values moved %d, differ by seed %d, by table %d of 100; want most", moved, differs, differsByTable)
+	}
+}
+
+func TestGenUniqueValUnboundedVarchar(t *testing.T) {
+	gen := NewValueGenerator(config.Config{})
+
+	value, err := gen.GenUniqueVal("public.t", model.Column{Name: "name", Typ: model.Varchar, IsUnique: true}, 12345)
+	if err != nil {
+		t.Fatalf("GenUniqueVal() unexpected error: %v", err)
+	}
+	if text := value.(string); len(text) != 5+uniqueTextPadding || text[:5] != "12345" {
+		t.Errorf("GenUniqueVal() = %q; want 12345 followed by %d letters", text, uniqueTextPadding)
+	}
+}
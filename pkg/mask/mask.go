@@ -0,0 +1,334 @@
+package mask
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"dbaker/pkg/config"
+	"dbaker/pkg/generator"
+	"dbaker/pkg/model"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrInvalidMask = errors.New("invalid mask")
+)
+
+// piiAnnotations are annotations of columns holding personal data, such columns are masked by default
+var piiAnnotations = []string{
+	"firstname", "lastname", "name", "username", "email", "phone", "phoneformatted",
+	"street", "streetname", "address", "city", "zip", "ipv4address", "ipv6address",
+	"ssn", "creditcardnumber", "achaccount", "achrouting",
+}
+
+/**
+ * Masker transforms rows read from a production database before they are written into the target.
+ * Hash and format masks are keyed by the mask key, equal source values give equal masked values
+ * (within a run, across runs when the same key is used), so joins and foreign keys still line up.
+ */
+type Masker struct {
+	gen *generator.ValueGenerator
+	key []byte
+}
+
+// NewMasker creates a masker keyed by the configured mask key, a random key is used when none is set
+func NewMasker(config config.Config, gen *generator.ValueGenerator) *Masker {
+	key := []byte(config.MaskKey)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+
+	return &Masker{
+		gen: gen,
+		key: key,
+	}
+}
+
+// MaskOf returns the mask applied to the column: the explicit one from the recipe, otherwise
+// columns with a personal data annotation are faked (or format masked when unique or referenced,
+// to keep the values distinct and consistent) and all other columns are kept
+func MaskOf(col model.Column) model.MaskKind {
+	if col.Mask != "" {
+		return col.Mask
+	}
+
+	if !isPII(col.Annotation) {
+		return model.MaskKeep
+	}
+
+	if col.IsUnique || col.ForeignKey != "" {
+		if isText(col.Typ) {
+			return model.MaskFormat
+		}
+		return model.MaskHash
+	}
+
+	return model.MaskFake
+}
+
+func isPII(annotation string) bool {
+	name, _, _ := strings.Cut(annotation, "(")
+	return slices.Contains(piiAnnotations, strings.ToLower(strings.TrimSpace(name)))
+}
+
+// Prepare validates masks of all columns up front, every invalid mask is reported at once
+func (m *Masker) Prepare(tables []model.Table) error {
+	var errs []error
+	for _, table := range tables {
+		for _, col := range table.Columns {
+			if err := prepareColumn(col); err != nil {
+				errs = append(errs, fmt.Errorf("table '%s.%s', column '%s': %w", table.Schema, table.Name, col.Name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func prepareColumn(col model.Column) error {
+	switch MaskOf(col) {
+	case model.MaskKeep, model.MaskFake:
+		return nil
+	case model.MaskNull:
+		if !col.IsNullable {
+			return fmt.Errorf("%w: null mask set on a column which is not nullable", ErrInvalidMask)
+		}
+	case model.MaskHash:
+		if !isText(col.Typ) && !isInt(col.Typ) && col.Typ != model.UUID && col.Typ != model.Boolean {
+			return fmt.Errorf("%w: hash mask is not supported for '%s' columns", ErrInvalidMask, col.Typ)
+		}
+	case model.MaskFormat:
+		if !isText(col.Typ) {
+			return fmt.Errorf("%w: format mask is supported for text columns only, not '%s'", ErrInvalidMask, col.Typ)
+		}
+	default:
+		return fmt.Errorf("%w: unknown mask '%s'", ErrInvalidMask, col.Mask)
+	}
+
+	return nil
+}
+
+// MaskRow returns masked copies of the row values, NULLs stay NULL whatever the mask is
+func (m *Masker) MaskRow(cols []model.Column, values []any, iter uint32) ([]any, error) {
+	masked := make([]any, len(values))
+	for index, col := range cols {
+		value, err := m.MaskVal(col, values[index], iter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to mask value of column '%s(%s)': %w", col.Name, col.Typ, err)
+		}
+
+		masked[index] = value
+	}
+
+	return masked, nil
+}
+
+func (m *Masker) MaskVal(col model.Column, value any, iter uint32) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch MaskOf(col) {
+	case model.MaskKeep:
+		return value, nil
+	case model.MaskNull:
+		return nil, nil
+	case model.MaskFake:
+		// the source value is not NULL, so neither is the fake one
+		col.IsNullable = false
+		return m.gen.GenVal(col, iter)
+	case model.MaskHash:
+		return m.hash(col, value)
+	case model.MaskFormat:
+		return m.format(col, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown mask '%s'", ErrInvalidMask, col.Mask)
+	}
+}
+
+// hash maps the value onto a keyed pseudonym of the column type
+func (m *Masker) hash(col model.Column, value any) (any, error) {
+	sum := m.keystream(value, sha256.Size)
+
+	switch {
+	case isText(col.Typ):
+		text := hex.EncodeToString(sum)
+		if col.MaxLength > 0 && uint(len(text)) > col.MaxLength {
+			text = text[:col.MaxLength]
+		}
+		return text, nil
+
+	case isInt(col.Typ):
+		return m.permuteInt(col, value)
+
+	case col.Typ == model.UUID:
+		sum[6] = (sum[6] & 0x0f) | 0x40
+		sum[8] = (sum[8] & 0x3f) | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16]), nil
+
+	case col.Typ == model.Boolean:
+		return sum[0]&1 == 1, nil
+
+	default:
+		return nil, fmt.Errorf("%w: hash mask is not supported for '%s' columns", ErrInvalidMask, col.Typ)
+	}
+}
+
+// permuteInt maps the integer onto another one of the column type by a keyed permutation, so that distinct
+// values stay distinct (unique columns). Positive values stay positive, pseudonymized keys are usually ids.
+func (m *Masker) permuteInt(col model.Column, value any) (any, error) {
+	var number int64
+	switch v := value.(type) {
+	case int64:
+		number = v
+	case int32:
+		number = int64(v)
+	case int16:
+		number = int64(v)
+	case int:
+		number = int64(v)
+	default:
+		return nil, fmt.Errorf("%w: hash mask expects an integer value, got %T", ErrInvalidMask, value)
+	}
+
+	low, high := int64(math.MinInt64), int64(math.MaxInt64)
+	switch col.Typ {
+	case model.SmallInt:
+		low, high = math.MinInt16, math.MaxInt16
+	case model.Int:
+		low, high = math.MinInt32, math.MaxInt32
+	}
+
+	key := binary.BigEndian.Uint64(m.keystream("int", 8))
+	if number > 0 {
+		return 1 + int64(generator.Permute(key, uint64(number-1), uint64(high))), nil
+	}
+	// 0 and the negative values, the lowest one negated overflows into uint64 just right
+	return -int64(generator.Permute(key, uint64(-(number+1))+1, uint64(-(low+1))+2)), nil
+}
+
+// format replaces digits by digits and letters by letters of the same case, any other character
+// (separators, '@', '.', ...) is kept in place. The last ascii digits and letters (as many as fit into
+// 62 bits) are permuted as a whole keyed by the text before them, so that distinct values stay distinct
+// (unique columns), any other digits and letters are substituted by a keystream of the value.
+func (m *Masker) format(col model.Column, value any) (any, error) {
+	text, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: format mask expects a text value, got %T", ErrInvalidMask, value)
+	}
+
+	runes := []rune(text)
+	var positions []int
+	for index, r := range runes {
+		if radix(r) > 0 {
+			positions = append(positions, index)
+		}
+	}
+
+	split := len(positions)
+	var domain, number uint64 = 1, 0
+	for split > 0 && domain <= (1<<62)/radix(runes[positions[split-1]]) {
+		split--
+		r := runes[positions[split]]
+		number += domain * ordinal(r)
+		domain *= radix(r)
+	}
+
+	head := len(runes)
+	if split < len(positions) {
+		head = positions[split]
+	}
+
+	// letters which are not ascii are substituted wherever they are
+	stream := m.keystream(value, len(runes))
+	for index, r := range runes {
+		if index >= head && radix(r) > 0 {
+			continue
+		}
+		switch {
+		case r >= '0' && r <= '9':
+			runes[index] = '0' + rune(stream[index]%10)
+		case unicode.IsUpper(r):
+			runes[index] = 'A' + rune(stream[index]%26)
+		case unicode.IsLetter(r):
+			runes[index] = 'a' + rune(stream[index]%26)
+		}
+	}
+
+	// values differing in the leading text are told apart by the key
+	key := binary.BigEndian.Uint64(m.keystream("format:"+string([]rune(text)[:head]), 8))
+	number = generator.Permute(key, number, domain)
+	for index := len(positions) - 1; index >= split; index-- {
+		r := runes[positions[index]]
+		runes[positions[index]] = fromOrdinal(r, number%radix(r))
+		number /= radix(r)
+	}
+
+	return string(runes), nil
+}
+
+// radix returns the number of characters an ascii digit or letter is replaced by, 0 for other characters
+func radix(r rune) uint64 {
+	switch {
+	case r >= '0' && r <= '9':
+		return 10
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		return 26
+	default:
+		return 0
+	}
+}
+
+func ordinal(r rune) uint64 {
+	switch {
+	case r >= '0' && r <= '9':
+		return uint64(r - '0')
+	case r >= 'a' && r <= 'z':
+		return uint64(r - 'a')
+	default:
+		return uint64(r - 'A')
+	}
+}
+
+// fromOrdinal returns the character of the ordinal in the character class of r
+func fromOrdinal(r rune, ordinal uint64) rune {
+	switch {
+	case r >= '0' && r <= '9':
+		return '0' + rune(ordinal)
+	case r >= 'a' && r <= 'z':
+		return 'a' + rune(ordinal)
+	default:
+		return 'A' + rune(ordinal)
+	}
+}
+
+// keystream derives n pseudo random bytes from the value, HMAC-SHA256 in counter mode
+func (m *Masker) keystream(value any, n int) []byte {
+	message := []byte(fmt.Sprint(value))
+
+	stream := make([]byte, 0, n+sha256.Size)
+	for counter := uint32(0); len(stream) < n || len(stream) == 0; counter++ {
+		mac := hmac.New(sha256.New, m.key)
+		mac.Write(message)
+		mac.Write(binary.BigEndian.AppendUint32(nil, counter))
+		stream = mac.Sum(stream)
+	}
+
+	return stream
+}
+
+func isText(typ model.ColumnType) bool {
+	return typ == model.Char || typ == model.Varchar || typ == model.Text
+}
+
+func isInt(typ model.ColumnType) bool {
+	return typ == model.SmallInt || typ == model.Int || typ == model.BigInt
+}
@@ -0,0 +1,190 @@
+package mask
+
+import (
+	"dbaker/pkg/config"
+	"dbaker/pkg/generator"
+	"dbaker/pkg/model"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"testing"
+)
+
+func newTestMasker(key string) *Masker {
+	return NewMasker(config.Config{MaskKey: key}, generator.NewValueGenerator(config.Config{}))
+}
+
+func TestMaskOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		column model.Column
+		want   model.MaskKind
+	}{
+		{name: "explicit mask", column: model.Column{Typ: model.Varchar, Annotation: "email", Mask: model.MaskNull}, want: model.MaskNull},
+		{name: "not annotated", column: model.Column{Typ: model.Varchar}, want: model.MaskKeep},
+		{name: "not personal data", column: model.Column{Typ: model.Varchar, Annotation: "color"}, want: model.MaskKeep},
+		{name: "personal data", column: model.Column{Typ: model.Varchar, Annotation: "firstName"}, want: model.MaskFake},
+		{name: "unique personal data", column: model.Column{Typ: model.Varchar, Annotation: "email", IsUnique: true}, want: model.MaskFormat},
+		{name: "unique non-text personal data", column: model.Column{Typ: model.BigInt, Annotation: "phone", IsUnique: true}, want: model.MaskHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaskOf(tt.column); got != tt.want {
+				t.Errorf("MaskOf() = %s; want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrepareValidatesMasks(t *testing.T) {
+	tests := []struct {
+		name    string
+		column  model.Column
+		wantErr bool
+	}{
+		{name: "null on nullable", column: model.Column{Typ: model.Text, IsNullable: true, Mask: model.MaskNull}},
+		{name: "null on not null", column: model.Column{Typ: model.Text, Mask: model.MaskNull}, wantErr: true},
+		{name: "format on text", column: model.Column{Typ: model.Varchar, Mask: model.MaskFormat}},
+		{name: "format on int", column: model.Column{Typ: model.Int, Mask: model.MaskFormat}, wantErr: true},
+		{name: "hash on date", column: model.Column{Typ: model.Date, Mask: model.MaskHash}, wantErr: true},
+		{name: "unknown mask", column: model.Column{Typ: model.Text, Mask: "shuffle"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables := []model.Table{{Name: "users", Schema: "public", Columns: []model.Column{tt.column}}}
+
+			err := newTestMasker("key").Prepare(tables)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Prepare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidMask) {
+				t.Errorf("Prepare() error = %v; want ErrInvalidMask", err)
+			}
+		})
+	}
+}
+
+func TestHashIsDeterministic(t *testing.T) {
+	columns := []model.Column{
+		{Typ: model.Varchar, MaxLength: 10, Mask: model.MaskHash},
+		{Typ: model.SmallInt, Mask: model.MaskHash},
+		{Typ: model.UUID, Mask: model.MaskHash},
+	}
+	values := []any{"john.doe", int64(42), "5f1c7a3e-8d2b-4c1a-9e0f-3b6d2a1c4e5f"}
+
+	first, err := newTestMasker("key").MaskRow(columns, values, 0)
+	if err != nil {
+		t.Fatalf("MaskRow() unexpected error: %v", err)
+	}
+	second, err := newTestMasker("key").MaskRow(columns, values, 1)
+	if err != nil {
+		t.Fatalf("MaskRow() unexpected error: %v", err)
+	}
+	other, err := newTestMasker("other key").MaskRow(columns, values, 0)
+	if err != nil {
+		t.Fatalf("MaskRow() unexpected error: %v", err)
+	}
+
+	for index := range columns {
+		if first[index] != second[index] {
+			t.Errorf("MaskRow()[%d] = %v and %v; want equal values for the same key", index, first[index], second[index])
+		}
+		if first[index] == other[index] {
+			t.Errorf("MaskRow()[%d] = %v; want different values for a different key", index, first[index])
+		}
+		if first[index] == values[index] {
+			t.Errorf("MaskRow()[%d] = %v; want the value masked", index, first[index])
+		}
+	}
+
+	if text := first[0].(string); len(text) != 10 {
+		t.Errorf("hash = %q; want value truncated to 10 characters", text)
+	}
+	if number := first[1].(int64); number < 1 || number > 32767 {
+		t.Errorf("hash = %d; want value within smallint range", number)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(first[2].(string)) {
+		t.Errorf("hash = %q; want a uuid", first[2])
+	}
+}
+
+func TestFormatPreservesShape(t *testing.T) {
+	masker := newTestMasker("key")
+	column := model.Column{Typ: model.Varchar, Mask: model.MaskFormat}
+
+	value, err := masker.MaskVal(column, "John.Doe-42@example.com", 0)
+	if err != nil {
+		t.Fatalf("MaskVal() unexpected error: %v", err)
+	}
+
+	if !regexp.MustCompile(`^[A-Z][a-z]{3}\.[A-Z][a-z]{2}-\d{2}@[a-z]{7}\.[a-z]{3}$`).MatchString(value.(string)) {
+		t.Errorf("MaskVal() = %q; want the shape of the source value", value)
+	}
+}
+
+func TestMaskKeepsNulls(t *testing.T) {
+	masker := newTestMasker("key")
+	for _, kind := range []model.MaskKind{model.MaskKeep, model.MaskFake, model.MaskHash, model.MaskFormat} {
+		value, err := masker.MaskVal(model.Column{Typ: model.Varchar, MaxLength: 10, IsNullable: true, Mask: kind}, nil, 0)
+		if err != nil || value != nil {
+			t.Errorf("MaskVal(%s, nil) = %v, %v; want nil", kind, value, err)
+		}
+	}
+
+	value, err := masker.MaskVal(model.Column{Typ: model.Varchar, MaxLength: 10, IsNullable: true, Mask: model.MaskFake}, "value", 0)
+	if err != nil || value == nil {
+		t.Errorf("MaskVal(fake) = %v, %v; want a fake value", value, err)
+	}
+}
+
+func TestMaskKeepsUniqueValuesDistinct(t *testing.T) {
+	masker := newTestMasker("key")
+	columns := []model.Column{
+		{Name: "id", Typ: model.SmallInt, IsUnique: true, Annotation: "ssn"},
+		{Name: "code", Typ: model.Char, MaxLength: 3, IsUnique: true, Annotation: "zip"},
+	}
+
+	for _, col := range columns {
+		t.Run(col.Name, func(t *testing.T) {
+			seen := make(map[any]bool)
+			for number := range 1000 {
+				var value any = int64(number - 500)
+				if col.Typ == model.Char {
+					value = fmt.Sprintf("%03d", number)
+				}
+
+				masked, err := masker.MaskVal(col, value, 0)
+				if err != nil {
+					t.Fatalf("MaskVal(%v) unexpected error: %v", value, err)
+				}
+				if seen[masked] {
+					t.Fatalf("MaskVal(%v) = %v repeats", value, masked)
+				}
+				seen[masked] = true
+
+				// the sign and the shape are kept
+				switch v := masked.(type) {
+				case int64:
+					if (v > 0) != (value.(int64) > 0) || v < math.MinInt16 || v > math.MaxInt16 {
+						t.Fatalf("MaskVal(%v) = %d; want a smallint of the same sign", value, v)
+					}
+				case string:
+					if !regexp.MustCompile(`^\d{3}$`).MatchString(v) {
+						t.Fatalf("MaskVal(%v) = %q; want three digits", value, v)
+					}
+				}
+			}
+		})
+	}
+
+	// the extremes of the type stay within the type
+	for _, value := range []int64{math.MinInt64, math.MaxInt64, 0} {
+		masked, err := masker.MaskVal(model.Column{Typ: model.BigInt, Mask: model.MaskHash}, value, 0)
+		if err != nil || (value > 0) != (masked.(int64) > 0) {
+			t.Errorf("MaskVal(%d) = %v, %v; want a bigint of the same sign", value, masked, err)
+		}
+	}
+}
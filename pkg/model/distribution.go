@@ -0,0 +1,111 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+type DistributionKind string
+
+const (
+	Uniform     DistributionKind = "uniform"
+	Normal      DistributionKind = "normal"
+	LogNormal   DistributionKind = "lognormal"
+	Exponential DistributionKind = "exponential"
+	Zipf        DistributionKind = "zipf"
+	Poisson     DistributionKind = "poisson"
+	Categorical DistributionKind = "categorical"
+	Histogram   DistributionKind = "histogram"
+)
+
+// Distribution describes how values of a numeric or temporal column are spread.
+// Parameters used by each kind:
+//   - uniform: min, max
+//   - normal: mean, stdDev (values are clamped to min, max when set)
+//   - lognormal: mu, sigma of the underlying normal distribution, shifted by min
+//   - exponential: rate, shifted by min
+//   - zipf: s (> 1), v (>= 1), values between min and max
+//   - poisson: lambda, shifted by min
+//   - categorical: categories with their weights
+//   - histogram: bounds of equally likely buckets, values are uniform within a bucket, optionally
+//     categories (most common values) drawn with their weight as a fraction of all values
+//     (at most 1 in total), the buckets cover the rest
+type Distribution struct {
+	Kind DistributionKind `json:"kind"`
+
+	Min    *Param `json:"min,omitempty"`
+	Max    *Param `json:"max,omitempty"`
+	Mean   *Param `json:"mean,omitempty"`
+	StdDev *Param `json:"stdDev,omitempty"`
+
+	Mu     *float64 `json:"mu,omitempty"`
+	Sigma  *float64 `json:"sigma,omitempty"`
+	Rate   *float64 `json:"rate,omitempty"`
+	S      *float64 `json:"s,omitempty"`
+	V      *float64 `json:"v,omitempty"`
+	Lambda *float64 `json:"lambda,omitempty"`
+
+	Categories []Category `json:"categories,omitempty"`
+	Bounds     []Param    `json:"bounds,omitempty"`
+}
+
+type Category struct {
+	Value  string  `json:"value"`
+	Weight float64 `json:"weight"`
+}
+
+// Param is a distribution parameter holding either a number or a string,
+// strings are used for temporal columns: dates, timestamps or durations (e.g. "720h")
+type Param struct {
+	Number float64
+	Text   string
+}
+
+func NumberParam(number float64) *Param {
+	return &Param{Number: number}
+}
+
+func TextParam(text string) *Param {
+	return &Param{Text: text}
+}
+
+func (p Param) IsText() bool {
+	return p.Text != ""
+}
+
+func (p Param) String() string {
+	if p.IsText() {
+		return p.Text
+	}
+	return strconv.FormatFloat(p.Number, 'g', -1, 64)
+}
+
+func (p Param) MarshalJSON() ([]byte, error) {
+	if p.IsText() {
+		return json.Marshal(p.Text)
+	}
+	return json.Marshal(p.Number)
+}
+
+func (p *Param) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		*p = Param{}
+		if err := json.Unmarshal(data, &p.Text); err != nil {
+			return err
+		}
+		if p.Text == "" {
+			return fmt.Errorf("distribution parameter can't be an empty string")
+		}
+		return nil
+	}
+
+	var number float64
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("distribution parameter must be a number or a string: %w", err)
+	}
+
+	*p = Param{Number: number}
+	return nil
+}
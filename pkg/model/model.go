@@ -1,9 +1,31 @@
 package model
 
+import "strings"
+
 type Table struct {
 	Name    string   `json:"tableName"`
 	Schema  string   `json:"tableSchema,omitempty"`
 	Columns []Column `json:"tableColumns"`
+
+	// schema the rows are written into when it differs from Schema, set from the schema mapping of the recipe
+	TargetSchema string `json:"-"`
+
+	// number of rows to generate, takes precedence over the dataset size given on the command line
+	RowCount uint32 `json:"rowCount,omitempty"`
+
+	// check constraints which could not be mapped onto column constraints
+	UnparsedChecks []string `json:"unparsedChecks,omitempty"`
+
+	// text column the tag of the run is written into, tells generated rows apart to delete them
+	TagColumn string `json:"tagColumn,omitempty"`
+}
+
+// WriteSchema returns the schema the rows of the table are written into
+func (t Table) WriteSchema() string {
+	if t.TargetSchema != "" {
+		return t.TargetSchema
+	}
+	return t.Schema
 }
 
 type ColumnType string
@@ -39,10 +61,88 @@ type Column struct {
 	Typ       ColumnType `json:"columnType"`
 	MaxLength uint       `json:"maxLength,omitempty"`
 
-	IsUnique    bool   `json:"isUnique"`
-	IsGenerated bool   `json:"isGenerated"`
-	IsNullable  bool   `json:"isNullable"`
-	ForeignKey  string `json:"foreginKey,omitempty"`
+	IsUnique    bool `json:"isUnique"`
+	IsGenerated bool `json:"isGenerated"`
+	IsNullable  bool `json:"isNullable"`
+
+	// referenced column in schema.table.column format
+	ForeignKey string `json:"foreignKey,omitempty"`
+
+	// probability (0-1) of generating NULL for nullable columns, overrides the global default
+	NullRatio *float64 `json:"nullRatio,omitempty"`
+
+	Constraints []Constraint `json:"constraints,omitempty"`
+
+	Distribution *Distribution `json:"distribution,omitempty"`
 
 	Annotation string `json:"annotation,omitempty"`
+
+	// transform applied to the column by the mask command, defaults based on the annotation
+	Mask MaskKind `json:"mask,omitempty"`
+
+	Stats *ColumnStats `json:"stats,omitempty"`
+}
+
+// ForeignKeyRef splits the referenced column of a foreign key column into its parts
+func (c Column) ForeignKeyRef() (schema string, table string, column string, ok bool) {
+	parts := strings.SplitN(c.ForeignKey, ".", 3)
+	if len(parts) != 3 {
+		return "", "", "", false
+	}
+
+	return parts[0], parts[1], parts[2], true
+}
+
+// ColumnStats describe the shape of the column data in a profiled database.
+// Min, Max and Histogram (equal frequency bucket bounds) are set for numeric and temporal columns only.
+type ColumnStats struct {
+	NullFraction  float64  `json:"nullFraction"`
+	DistinctCount float64  `json:"distinctCount"`
+	AvgWidth      int      `json:"avgWidth"`
+	Min           string   `json:"min,omitempty"`
+	Max           string   `json:"max,omitempty"`
+	Histogram     []string `json:"histogram,omitempty"`
+	// most common values with their frequency among all rows
+	MostCommon []Category `json:"mostCommon,omitempty"`
+}
+
+type MaskKind string
+
+const (
+	// copy the value as-is
+	MaskKeep MaskKind = "keep"
+	// replace the value by a generated one (annotation, distribution, ...)
+	MaskFake MaskKind = "fake"
+	// replace the value by a keyed hash, equal values stay equal so joins still line up
+	MaskHash MaskKind = "hash"
+	// replace letters and digits by keyed pseudo random ones, keeping length, case and punctuation
+	MaskFormat MaskKind = "format"
+	// replace the value by NULL
+	MaskNull MaskKind = "null"
+)
+
+type ConstraintOp string
+
+const (
+	OpGt  ConstraintOp = ">"
+	OpGte ConstraintOp = ">="
+	OpLt  ConstraintOp = "<"
+	OpLte ConstraintOp = "<="
+	OpEq  ConstraintOp = "="
+	OpNe  ConstraintOp = "<>"
+	OpIn  ConstraintOp = "in"
+)
+
+// NowLiteral stands for the current time (now(), CURRENT_DATE, ...) and is resolved during generation
+const NowLiteral = "now()"
+
+// Constraint is a single condition from a CHECK constraint, narrowed down to the column it restricts.
+// The right hand side is either a literal Value, a list of Values (in) or another Column of the same table.
+// OnLength applies the condition to the length of the value instead of the value itself.
+type Constraint struct {
+	Op       ConstraintOp `json:"op"`
+	Value    string       `json:"value,omitempty"`
+	Values   []string     `json:"values,omitempty"`
+	Column   string       `json:"column,omitempty"`
+	OnLength bool         `json:"onLength,omitempty"`
 }
@@ -0,0 +1,208 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// interactive progress is redrawn in place at most this often
+	redrawInterval = 100 * time.Millisecond
+	// non interactive progress (CI logs) is printed as a line this often
+	lineInterval = 10 * time.Second
+	barWidth     = 20
+)
+
+type Status string
+
+const (
+	StatusOK     Status = "ok"
+	StatusFailed Status = "failed"
+	StatusDryRun Status = "dry-run"
+)
+
+// Summary sums up a run, written as JSON for CI
+type Summary struct {
+	Status  Status         `json:"status"`
+	Rows    uint64         `json:"rows"`
+	Seconds float64        `json:"seconds"`
+	Tables  []TableSummary `json:"tables"`
+	Error   string         `json:"error,omitempty"`
+}
+
+type TableSummary struct {
+	Table string `json:"table"`
+	// rows written by the run, rows of a resumed run written before are not included
+	Rows          uint32  `json:"rows"`
+	Total         uint32  `json:"total"`
+	Seconds       float64 `json:"seconds"`
+	RowsPerSecond float64 `json:"rowsPerSecond"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// Tracker tracks the rows written per table and renders the progress, redrawn in place on a terminal
+// and as a line every few seconds otherwise. A tracker without output only sums the run up.
+type Tracker struct {
+	out         io.Writer
+	interactive bool
+	now         func() time.Time
+	start       time.Time
+	tables      []TableSummary
+	current     *table
+	lastRender  time.Time
+}
+
+type table struct {
+	name    string
+	resumed uint32
+	done    uint32
+	total   uint32
+	start   time.Time
+}
+
+func NewTracker(out io.Writer) *Tracker {
+	return &Tracker{
+		out,
+		isTerminal(out),
+		time.Now,
+		time.Now(),
+		nil,
+		nil,
+		time.Time{},
+	}
+}
+
+// StartTable starts tracking the table, resumed rows were written by an earlier run
+func (t *Tracker) StartTable(name string, resumed uint32, total uint32) {
+	t.current = &table{name, resumed, resumed, total, t.now()}
+	t.lastRender = time.Time{}
+	t.render(false)
+}
+
+// Add counts the written rows of the current table
+func (t *Tracker) Add(rows uint32) {
+	t.current.done += rows
+
+	interval := lineInterval
+	if t.interactive {
+		interval = redrawInterval
+	}
+	if t.now().Sub(t.lastRender) >= interval {
+		t.render(false)
+	}
+}
+
+// FinishTable renders the final progress of the current table and adds it to the summary
+func (t *Tracker) FinishTable(err error) {
+	if t.current == nil {
+		return
+	}
+	t.render(true)
+
+	seconds := t.now().Sub(t.current.start).Seconds()
+	summary := TableSummary{
+		Table:         t.current.name,
+		Rows:          t.current.done - t.current.resumed,
+		Total:         t.current.total,
+		Seconds:       seconds,
+		RowsPerSecond: rate(t.current.done-t.current.resumed, seconds),
+	}
+	if err != nil {
+		summary.Error = err.Error()
+	}
+
+	t.tables = append(t.tables, summary)
+	t.current = nil
+}
+
+// Summary sums the run up, the status is derived from the error unless given
+func (t *Tracker) Summary(status Status, err error) Summary {
+	summary := Summary{Status: status, Seconds: t.now().Sub(t.start).Seconds(), Tables: t.tables}
+	if summary.Tables == nil {
+		summary.Tables = []TableSummary{}
+	}
+	for _, table := range t.tables {
+		summary.Rows += uint64(table.Rows)
+	}
+	if err != nil {
+		summary.Status = StatusFailed
+		summary.Error = err.Error()
+	}
+	return summary
+}
+
+// WriteSummary writes the summary as JSON into the file, or to stdout for "-"
+func WriteSummary(filePath string, summary Summary) error {
+	contents, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	contents = append(contents, '\n')
+
+	if filePath == "-" {
+		_, err = os.Stdout.Write(contents)
+		return err
+	}
+	return os.WriteFile(filePath, contents, 0644)
+}
+
+func (t *Tracker) render(final bool) {
+	if t.out == nil || t.current == nil {
+		return
+	}
+	t.lastRender = t.now()
+
+	line := t.line()
+	switch {
+	case t.interactive && final:
+		fmt.Fprintf(t.out, "\r\033[K%s\n", line)
+	case t.interactive:
+		fmt.Fprintf(t.out, "\r\033[K%s", line)
+	default:
+		fmt.Fprintln(t.out, line)
+	}
+}
+
+// line renders the progress of the current table, e.g.
+// public.users [#########-----------] 4500/10000  45% 1500 rows/s ETA 4s
+func (t *Tracker) line() string {
+	current := t.current
+
+	fraction := 1.0
+	if current.total > 0 {
+		fraction = float64(current.done) / float64(current.total)
+	}
+	filled := int(fraction * barWidth)
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", barWidth-filled)
+
+	written := current.done - current.resumed
+	perSecond := rate(written, t.now().Sub(current.start).Seconds())
+
+	eta := "-"
+	if perSecond > 0 {
+		remaining := float64(current.total-current.done) / perSecond
+		eta = (time.Duration(remaining) * time.Second).String()
+	}
+
+	return fmt.Sprintf("%s [%s] %d/%d %3.0f%% %.0f rows/s ETA %s", current.name, bar, current.done, current.total, fraction*100, perSecond, eta)
+}
+
+func rate(rows uint32, seconds float64) float64 {
+	if seconds <= 0 {
+		return 0
+	}
+	return float64(rows) / seconds
+}
+
+func isTerminal(out io.Writer) bool {
+	file, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
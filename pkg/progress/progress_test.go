@@ -0,0 +1,108 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestTracker(out io.Writer) (*Tracker, *clock) {
+	clock := &clock{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tracker := NewTracker(out)
+	tracker.now = func() time.Time { return clock.now }
+	tracker.start = clock.now
+	return tracker, clock
+}
+
+func TestTrackerLines(t *testing.T) {
+	var out bytes.Buffer
+	tracker, clock := newTestTracker(&out)
+
+	tracker.StartTable("public.users", 0, 100)
+	for range 15 {
+		clock.advance(time.Second)
+		tracker.Add(1)
+	}
+	tracker.FinishTable(nil)
+
+	// non interactive output prints a line at start, every 10 seconds and at the end
+	want := []string{
+		"public.users [--------------------] 0/100   0% 0 rows/s ETA -",
+		"public.users [##------------------] 10/100  10% 1 rows/s ETA 1m30s",
+		"public.users [###-----------------] 15/100  15% 1 rows/s ETA 1m25s",
+	}
+	got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("progress lines =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestTrackerSummary(t *testing.T) {
+	tracker, clock := newTestTracker(nil)
+
+	// a resumed table counts the rows written by the run only
+	tracker.StartTable("public.users", 40, 100)
+	clock.advance(2 * time.Second)
+	tracker.Add(60)
+	tracker.FinishTable(nil)
+
+	tracker.StartTable("public.orders", 0, 10)
+	clock.advance(time.Second)
+	tracker.Add(3)
+	tracker.FinishTable(errors.New("duplicate key"))
+
+	summary := tracker.Summary(StatusOK, errors.New("run failed"))
+
+	if summary.Status != StatusFailed || summary.Error != "run failed" {
+		t.Errorf("Summary() status = %s (%s); want failed (run failed)", summary.Status, summary.Error)
+	}
+	if summary.Rows != 63 || summary.Seconds != 3 {
+		t.Errorf("Summary() rows = %d in %vs; want 63 in 3s", summary.Rows, summary.Seconds)
+	}
+	want := []TableSummary{
+		{Table: "public.users", Rows: 60, Total: 100, Seconds: 2, RowsPerSecond: 30},
+		{Table: "public.orders", Rows: 3, Total: 10, Seconds: 1, RowsPerSecond: 3, Error: "duplicate key"},
+	}
+	if len(summary.Tables) != len(want) {
+		t.Fatalf("Summary() tables = %+v; want %+v", summary.Tables, want)
+	}
+	for index := range want {
+		if summary.Tables[index] != want[index] {
+			t.Errorf("Summary() table[%d] = %+v; want %+v", index, summary.Tables[index], want[index])
+		}
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "summary.json")
+
+	if err := WriteSummary(filePath, NewTracker(nil).Summary(StatusDryRun, nil)); err != nil {
+		t.Fatalf("WriteSummary() unexpected error: %v", err)
+	}
+
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("failed to read summary: %v", err)
+	}
+	var summary map[string]any
+	if err := json.Unmarshal(contents, &summary); err != nil {
+		t.Fatalf("summary is not JSON: %v", err)
+	}
+	if summary["status"] != "dry-run" || summary["tables"] == nil {
+		t.Errorf("summary = %s; want dry-run status and empty tables", contents)
+	}
+}
@@ -0,0 +1,25 @@
+package recipe
+
+import "fmt"
+
+// InvalidError reports the problems of a recipe (file), it matches ErrInvalidRecipe
+type InvalidError struct {
+	// empty for recipes which were not read from a file, e.g. baked ones
+	FilePath string
+	Err      error
+}
+
+func (e *InvalidError) Error() string {
+	if e.FilePath == "" {
+		return fmt.Sprintf("%s:\n%s", ErrInvalidRecipe, e.Err)
+	}
+	return fmt.Sprintf("%s %s:\n%s", ErrInvalidRecipe, e.FilePath, e.Err)
+}
+
+func (e *InvalidError) Unwrap() error {
+	return e.Err
+}
+
+func (e *InvalidError) Is(target error) bool {
+	return target == ErrInvalidRecipe
+}
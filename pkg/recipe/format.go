@@ -0,0 +1,219 @@
+package recipe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// format is the recipe file format, detected by the file extension (JSON by default).
+// YAML and TOML recipes are converted from/to JSON, so they use the same property names,
+// are validated against the same schema and round trip with JSON recipes.
+type format string
+
+const (
+	formatJSON format = "json"
+	formatYAML format = "yaml"
+	formatTOML format = "toml"
+)
+
+func formatOf(filePath string) format {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".toml":
+		return formatTOML
+	default:
+		return formatJSON
+	}
+}
+
+// toJSON converts the recipe file contents into JSON
+func toJSON(format format, contents []byte) ([]byte, error) {
+	var value any
+	switch format {
+	case formatYAML:
+		if err := yaml.Unmarshal(contents, &value); err != nil {
+			return nil, err
+		}
+	case formatTOML:
+		if err := toml.Unmarshal(contents, &value); err != nil {
+			return nil, err
+		}
+	default:
+		return contents, nil
+	}
+
+	return json.Marshal(value)
+}
+
+// fromJSON converts JSON into the recipe file format, property order is kept where the format allows it
+func fromJSON(format format, contents []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.UseNumber()
+
+	switch format {
+	case formatYAML:
+		node, err := yamlNode(decoder)
+		if err != nil {
+			return nil, err
+		}
+
+		buffer := bytes.Buffer{}
+		encoder := yaml.NewEncoder(&buffer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(node); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+
+	case formatTOML:
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		buffer := bytes.Buffer{}
+		if err := toml.NewEncoder(&buffer).Encode(tomlValue(value)); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+
+	default:
+		return contents, nil
+	}
+}
+
+// yamlNode builds the YAML node of the next JSON value keeping the order of object properties
+func yamlNode(decoder *json.Decoder) (*yaml.Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		if t == '{' {
+			node.Kind = yaml.MappingNode
+		}
+
+		for decoder.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprint(key)})
+			}
+
+			value, err := yamlNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+
+		// closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: t}, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(t.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: t.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(t)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
+
+// tomlValue converts JSON numbers into integers or floats and drops nulls, which TOML does not have
+func tomlValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if item == nil {
+				delete(v, key)
+				continue
+			}
+			v[key] = tomlValue(item)
+		}
+		return v
+	case []any:
+		for index, item := range v {
+			v[index] = tomlValue(item)
+		}
+		return v
+	case json.Number:
+		if integer, err := v.Int64(); err == nil {
+			return integer
+		}
+		float, _ := v.Float64()
+		return float
+	default:
+		return v
+	}
+}
+
+// hasComments reports whether the recipe file contents hold comments, which are lost when dbaker rewrites the file
+func hasComments(format format, contents []byte) (bool, error) {
+	switch format {
+	case formatYAML:
+		var node yaml.Node
+		if err := yaml.Unmarshal(contents, &node); err != nil {
+			return false, err
+		}
+		return yamlHasComments(&node), nil
+	case formatTOML:
+		return tomlHasComments(string(contents)), nil
+	default:
+		return false, nil
+	}
+}
+
+func yamlHasComments(node *yaml.Node) bool {
+	if node.HeadComment != "" || node.LineComment != "" || node.FootComment != "" {
+		return true
+	}
+	return slices.ContainsFunc(node.Content, yamlHasComments)
+}
+
+// tomlHasComments looks for a # outside of strings, the TOML decoder drops comments
+func tomlHasComments(text string) bool {
+	for index := 0; index < len(text); index++ {
+		switch {
+		case strings.HasPrefix(text[index:], `"""`) || strings.HasPrefix(text[index:], `'''`):
+			delimiter := text[index : index+3]
+			for index += 3; index < len(text) && !strings.HasPrefix(text[index:], delimiter); index++ {
+				if delimiter == `"""` && text[index] == '\\' {
+					index++
+				}
+			}
+			index += 2
+		case text[index] == '"' || text[index] == '\'':
+			quote := text[index]
+			for index++; index < len(text) && text[index] != quote && text[index] != '\n'; index++ {
+				if quote == '"' && text[index] == '\\' {
+					index++
+				}
+			}
+		case text[index] == '#':
+			return true
+		}
+	}
+	return false
+}
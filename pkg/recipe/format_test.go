@@ -0,0 +1,160 @@
+package recipe
+
+import (
+	"dbaker/pkg/model"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func formatTestTables() []*model.Table {
+	ratio := 0.1
+	return []*model.Table{
+		{
+			Name:     "users",
+			Schema:   "public",
+			RowCount: 100,
+			Columns: []model.Column{
+				{Name: "id", Typ: model.Int, IsUnique: true, IsGenerated: true},
+				{Name: "email", Typ: model.Varchar, MaxLength: 255, Annotation: "email", Mask: model.MaskFormat},
+				{Name: "group_id", Typ: model.Int, ForeignKey: "public.groups.id"},
+				{Name: "score", Typ: model.Double, IsNullable: true, NullRatio: &ratio, Distribution: &model.Distribution{
+					Kind: model.Normal, Mean: model.NumberParam(50.5), StdDev: model.NumberParam(10),
+				}},
+				{Name: "created_at", Typ: model.Timestamp, Distribution: &model.Distribution{
+					Kind: model.Uniform, Min: model.TextParam("2024-01-01"), Max: model.TextParam("2024-12-31"),
+				}},
+				{Name: "code", Typ: model.Varchar, Annotation: "oneOf(1,2)", Constraints: []model.Constraint{
+					{Op: model.OpIn, Values: []string{"1", "2"}},
+				}},
+			},
+		},
+	}
+}
+
+func TestFormatsRoundTrip(t *testing.T) {
+	for _, extension := range []string{".json", ".yaml", ".yml", ".toml"} {
+		t.Run(extension, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "test.recipe"+extension)
+			tables := formatTestTables()
+
+			if err := Write(filePath, tables); err != nil {
+				t.Fatalf("Write() unexpected error: %v", err)
+			}
+
+			readTables, err := Read(filePath)
+			if err != nil {
+				t.Fatalf("Read() unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(pointers(readTables), tables) {
+				t.Errorf("Read() = %+v; want %+v", readTables[0], tables[0])
+			}
+		})
+	}
+}
+
+func TestYAMLKeepsPropertyOrder(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.recipe.yaml")
+	if err := Write(filePath, formatTestTables()); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("failed to read recipe: %v", err)
+	}
+
+	text := string(contents)
+	if !strings.HasPrefix(text, "version: 2\ntables:\n  - tableName: users\n") {
+		t.Errorf("Write() = %s; want properties in the JSON order", text)
+	}
+	// numeric looking strings stay strings
+	if !strings.Contains(text, `- "1"`) {
+		t.Errorf("Write() = %s; want quoted numeric strings", text)
+	}
+}
+
+func TestReadHandWrittenYAML(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.recipe.yaml")
+	contents := `# seed data of the users service
+version: 2
+tables:
+  - tableName: users
+    tableSchema: public
+    rowCount: 50 # a handful is enough locally
+    tableColumns:
+      - columnName: role
+        columnType: varchar
+        # roles are weighted like in production
+        distribution:
+          kind: categorical
+          categories:
+            - { value: admin, weight: 1 }
+            - { value: viewer, weight: 9 }
+      - columnName: age
+        columnType: int4x
+`
+	if err := os.WriteFile(filePath, []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write recipe: %v", err)
+	}
+
+	_, err := Read(filePath)
+	if err == nil || !strings.Contains(err.Error(), "tables[0].tableColumns[1].columnType: unknown type 'int4x'") {
+		t.Errorf("Read() error = %v; want the invalid column type reported", err)
+	}
+}
+
+func TestHasComments(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   format
+		contents string
+		expected bool
+	}{
+		{name: "yaml line comment", format: formatYAML, contents: "version: 2 # current\ntables: []\n", expected: true},
+		{name: "yaml head comment", format: formatYAML, contents: "version: 2\ntables:\n  # users first\n  - tableName: users\n", expected: true},
+		{name: "yaml hash within a string", format: formatYAML, contents: "version: 2\ntables:\n  - { tableName: \"users#1\" }\n", expected: false},
+		{name: "toml comment", format: formatTOML, contents: "version = 2 # current\n", expected: true},
+		{name: "toml hash within strings", format: formatTOML, contents: "a = \"#1 \\\" #2\"\nb = '#3'\nc = \"\"\"\n#4\n\"\"\"\nd = '''#5'''\n", expected: false},
+		{name: "toml comment after a multi-line string", format: formatTOML, contents: "c = \"\"\"\n#4\n\"\"\"\n# note\n", expected: true},
+		{name: "json", format: formatJSON, contents: `{"version": 2, "tables": [{"tableName": "#"}]}`, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commented, err := hasComments(tc.format, []byte(tc.contents))
+			if err != nil {
+				t.Fatalf("hasComments() unexpected error: %v", err)
+			}
+			if commented != tc.expected {
+				t.Errorf("hasComments(%q) = %v; want %v", tc.contents, commented, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCheckComments(t *testing.T) {
+	dir := t.TempDir()
+	writeRecipeFile(t, filepath.Join(dir, "shop", "orders.recipe.yaml"), `
+version: 2
+tables:
+  - tableName: orders
+    tableSchema: shop
+    rowCount: 500 # like production
+    tableColumns: []
+`)
+	writeRecipeFile(t, filepath.Join(dir, "main.recipe.yaml"), "version: 2\ninclude: [shop/]\ntables: []\n")
+
+	err := CheckComments(filepath.Join(dir, "main.recipe.yaml"))
+	if !errors.Is(err, ErrCommentsLost) || !strings.Contains(err.Error(), "orders.recipe.yaml") {
+		t.Errorf("CheckComments() error = %v; want %v naming orders.recipe.yaml", err, ErrCommentsLost)
+	}
+
+	if err := CheckComments(filepath.Join(dir, "missing.recipe.yaml")); err != nil {
+		t.Errorf("CheckComments() of a missing recipe unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package recipe
+
+import (
+	"dbaker/pkg/model"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// reader reads the recipe files, includes first
+type reader struct {
+	// files being read, to detect include cycles
+	visiting map[string]bool
+	// files the tables read so far come from, to report duplicates
+	origins map[string]string
+	mapping map[string]mappedSchema
+	tables  []model.Table
+}
+
+type mappedSchema struct {
+	schema   string
+	filePath string
+}
+
+func (r *reader) read(recipePath string) error {
+	filePaths := []string{recipePath}
+	if isDir(recipePath) {
+		var err error
+		if filePaths, err = recipeFiles(recipePath); err != nil {
+			return err
+		}
+	}
+
+	for _, filePath := range filePaths {
+		absolutePath, err := filepath.Abs(filePath)
+		if err != nil {
+			return err
+		}
+		if r.visiting[absolutePath] {
+			return &InvalidError{filePath, errors.New("include cycle")}
+		}
+
+		recipe, _, err := readFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		for schema, target := range recipe.SchemaMapping {
+			if mapped, ok := r.mapping[schema]; ok && mapped.schema != target {
+				return &InvalidError{filePath, fmt.Errorf("schema %s is mapped to %s, but to %s in %s", schema, target, mapped.schema, mapped.filePath)}
+			}
+			r.mapping[schema] = mappedSchema{target, filePath}
+		}
+
+		r.visiting[absolutePath] = true
+		for _, included := range recipe.Include {
+			if err := r.read(resolveInclude(filePath, included)); err != nil {
+				return err
+			}
+		}
+		delete(r.visiting, absolutePath)
+
+		for _, table := range recipe.Tables {
+			name := tableName(*table)
+			if origin, ok := r.origins[name]; ok {
+				return &InvalidError{filePath, fmt.Errorf("table %s is already defined in %s", name, origin)}
+			}
+
+			r.origins[name] = filePath
+			r.tables = append(r.tables, *table)
+		}
+	}
+
+	return nil
+}
+
+// writeIncluded writes the tables defined in the included recipes back into them, returns the remaining tables.
+// Included recipes defining none of the tables are not rewritten.
+func writeIncluded(includePaths []string, tables []*model.Table) ([]*model.Table, error) {
+	for _, includePath := range includePaths {
+		defined, err := Read(includePath)
+		if err != nil {
+			return nil, err
+		}
+
+		var included, rest []*model.Table
+		for _, table := range tables {
+			if slices.ContainsFunc(defined, func(definedTable model.Table) bool { return sameTable(definedTable, *table) }) {
+				included = append(included, table)
+			} else {
+				rest = append(rest, table)
+			}
+		}
+
+		// files holding none of the tables are left untouched, e.g. when only some tables are introspected
+		if len(included) == 0 {
+			continue
+		}
+		if err := Write(includePath, included); err != nil {
+			return nil, err
+		}
+		tables = rest
+	}
+
+	return tables, nil
+}
+
+// writeDir writes the tables into the recipe directory, tables without a file get a new JSON file
+func writeDir(dirPath string, tables []*model.Table) error {
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return fmt.Errorf("failed to create recipe directory: %w", err)
+	}
+
+	filePaths, err := recipeFiles(dirPath)
+	if err != nil {
+		return err
+	}
+
+	rest, err := writeIncluded(filePaths, tables)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range rest {
+		filePath := filepath.Join(dirPath, fmt.Sprintf("%s.%s.recipe.json", table.Schema, table.Name))
+		if err := writeFile(filePath, &Recipe{Version: CurrentVersion, Tables: []*model.Table{table}}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recipeFiles lists the recipe files of the directory in name order, subdirectories are not read
+func recipeFiles(dirPath string) ([]string, error) {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recipe directory: %w", err)
+	}
+
+	var filePaths []string
+	for _, entry := range entries {
+		extension := strings.ToLower(filepath.Ext(entry.Name()))
+		if !entry.IsDir() && slices.Contains([]string{".json", ".yaml", ".yml", ".toml"}, extension) {
+			filePaths = append(filePaths, filepath.Join(dirPath, entry.Name()))
+		}
+	}
+
+	return filePaths, nil
+}
+
+// resolveInclude resolves the included path relative to the including file
+func resolveInclude(filePath string, included string) string {
+	if filepath.IsAbs(included) {
+		return included
+	}
+	return filepath.Join(filepath.Dir(filePath), included)
+}
+
+// isDir reports whether the recipe path is a directory, paths ending with a separator are directories
+// even when they don't exist yet (they are created on write)
+func isDir(recipePath string) bool {
+	if strings.HasSuffix(recipePath, "/") || strings.HasSuffix(recipePath, string(filepath.Separator)) {
+		return true
+	}
+
+	info, err := os.Stat(recipePath)
+	return err == nil && info.IsDir()
+}
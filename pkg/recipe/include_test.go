@@ -0,0 +1,328 @@
+package recipe
+
+import (
+	"dbaker/pkg/model"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeRecipeFile(t *testing.T, filePath string, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filePath, []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write recipe: %v", err)
+	}
+}
+
+func tableNames(tables []model.Table) []string {
+	var names []string
+	for _, table := range tables {
+		names = append(names, tableName(table))
+	}
+	return names
+}
+
+func TestReadIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeRecipeFile(t, filepath.Join(dir, "shop", "customers.recipe.yaml"), `
+version: 2
+tables:
+  - { tableName: customers, tableSchema: shop, tableColumns: [{ columnName: id, columnType: int4 }] }
+`)
+	writeRecipeFile(t, filepath.Join(dir, "shop", "orders.recipe.json"),
+		`{"version": 2, "tables": [{"tableName": "orders", "tableSchema": "shop", "tableColumns": []}]}`)
+	writeRecipeFile(t, filepath.Join(dir, "shop", "notes.txt"), "not a recipe")
+	writeRecipeFile(t, filepath.Join(dir, "public.recipe.json"),
+		`{"version": 2, "tables": [{"tableName": "users", "tableSchema": "public", "tableColumns": []}]}`)
+	writeRecipeFile(t, filepath.Join(dir, "main.recipe.json"),
+		`{"version": 2, "include": ["public.recipe.json", "shop"], "tables": [{"tableName": "audit", "tableSchema": "public", "tableColumns": []}]}`)
+
+	tables, err := Read(filepath.Join(dir, "main.recipe.json"))
+	if err != nil {
+		t.Fatalf("Read() unexpected error: %v", err)
+	}
+
+	want := []string{"public.users", "shop.customers", "shop.orders", "public.audit"}
+	if got := tableNames(tables); !slices.Equal(got, want) {
+		t.Errorf("Read() tables = %v; want %v", got, want)
+	}
+}
+
+func TestReadIncludeErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "include cycle",
+			files: map[string]string{
+				"main.recipe.json":  `{"version": 2, "include": ["other.recipe.json"], "tables": []}`,
+				"other.recipe.json": `{"version": 2, "include": ["main.recipe.json"], "tables": []}`,
+			},
+			wantErr: "include cycle",
+		},
+		{
+			name: "table defined twice",
+			files: map[string]string{
+				"main.recipe.json":  `{"version": 2, "include": ["other.recipe.json"], "tables": [{"tableName": "users", "tableSchema": "public", "tableColumns": []}]}`,
+				"other.recipe.json": `{"version": 2, "tables": [{"tableName": "users", "tableSchema": "public", "tableColumns": []}]}`,
+			},
+			wantErr: "table public.users is already defined in",
+		},
+		{
+			name: "missing include",
+			files: map[string]string{
+				"main.recipe.json": `{"version": 2, "include": ["missing.recipe.json"], "tables": []}`,
+			},
+			wantErr: "failed to read recipe",
+		},
+		{
+			name: "invalid include",
+			files: map[string]string{
+				"main.recipe.json": `{"version": 2, "include": [""], "tables": []}`,
+			},
+			wantErr: "include[0]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, contents := range tc.files {
+				writeRecipeFile(t, filepath.Join(dir, name), contents)
+			}
+
+			_, err := Read(filepath.Join(dir, "main.recipe.json"))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("Read() error = %v; want containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeRecipeFile(t, filepath.Join(dir, "shop.recipe.yaml"), `
+version: 2
+tables:
+  - { tableName: orders, tableSchema: shop, tableColumns: [] }
+`)
+	writeRecipeFile(t, filepath.Join(dir, "main.recipe.json"),
+		`{"version": 2, "include": ["shop.recipe.yaml"], "tables": [{"tableName": "users", "tableSchema": "public", "tableColumns": []}]}`)
+
+	tables := []*model.Table{
+		{Name: "users", Schema: "public", RowCount: 10, Columns: []model.Column{}},
+		{Name: "orders", Schema: "shop", RowCount: 20, Columns: []model.Column{}},
+		{Name: "groups", Schema: "public", RowCount: 30, Columns: []model.Column{}},
+	}
+	if err := Write(filepath.Join(dir, "main.recipe.json"), tables); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+
+	included, _, err := readFile(filepath.Join(dir, "shop.recipe.yaml"))
+	if err != nil {
+		t.Fatalf("readFile() unexpected error: %v", err)
+	}
+	if len(included.Tables) != 1 || included.Tables[0].RowCount != 20 {
+		t.Errorf("included recipe tables = %+v; want shop.orders with 20 rows", included.Tables)
+	}
+
+	main, _, err := readFile(filepath.Join(dir, "main.recipe.json"))
+	if err != nil {
+		t.Fatalf("readFile() unexpected error: %v", err)
+	}
+	if !slices.Equal(main.Include, []string{"shop.recipe.yaml"}) {
+		t.Errorf("main recipe include = %v; want [shop.recipe.yaml]", main.Include)
+	}
+	if got := tableNames(values(main.Tables)); !slices.Equal(got, []string{"public.users", "public.groups"}) {
+		t.Errorf("main recipe tables = %v; want [public.users public.groups]", got)
+	}
+}
+
+func TestWriteDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "recipe") + "/"
+	writeRecipeFile(t, filepath.Join(dir, "users.recipe.yaml"), `
+version: 2
+tables:
+  - { tableName: users, tableSchema: public, tableColumns: [] }
+`)
+
+	tables := []*model.Table{
+		{Name: "users", Schema: "public", RowCount: 10, Columns: []model.Column{}},
+		{Name: "orders", Schema: "shop", RowCount: 20, Columns: []model.Column{}},
+	}
+	if err := Write(dir, tables); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "shop.orders.recipe.json")); err != nil {
+		t.Errorf("new table file not written: %v", err)
+	}
+
+	readTables, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read() unexpected error: %v", err)
+	}
+	if got := tableNames(readTables); !slices.Equal(got, []string{"shop.orders", "public.users"}) {
+		t.Errorf("Read() tables = %v; want [shop.orders public.users]", got)
+	}
+	if readTables[1].RowCount != 10 {
+		t.Errorf("users row count = %d; want 10", readTables[1].RowCount)
+	}
+}
+
+func TestWriteLeavesFilesOfOtherTablesUntouched(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "recipe") + "/"
+	orders := `
+# hand-written
+version: 2
+tables:
+  - { tableName: orders, tableSchema: shop, rowCount: 500, tableColumns: [] }
+`
+	writeRecipeFile(t, filepath.Join(dir, "orders.recipe.yaml"), orders)
+	writeRecipeFile(t, filepath.Join(dir, "users.recipe.yaml"), `
+version: 2
+tables:
+  - { tableName: users, tableSchema: public, tableColumns: [] }
+`)
+
+	if err := Write(dir, []*model.Table{{Name: "users", Schema: "public", RowCount: 10, Columns: []model.Column{}}}); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+
+	contents, err := os.ReadFile(filepath.Join(dir, "orders.recipe.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read recipe: %v", err)
+	}
+	if string(contents) != orders {
+		t.Errorf("orders recipe = %q; want it untouched", contents)
+	}
+
+	tables, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read() unexpected error: %v", err)
+	}
+	if got := tableNames(tables); !slices.Equal(got, []string{"shop.orders", "public.users"}) || tables[1].RowCount != 10 {
+		t.Errorf("Read() tables = %v; want [shop.orders public.users] with the written users", got)
+	}
+}
+
+func TestWriteKeepsUnreadableRecipe(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "main.recipe.json")
+	contents := `{"version": 2, "include": ["shop.recipe.yaml"], "tables": [`
+	writeRecipeFile(t, filePath, contents)
+
+	err := Write(filePath, []*model.Table{{Name: "users", Schema: "public", Columns: []model.Column{}}})
+	if !errors.Is(err, ErrInvalidRecipe) {
+		t.Fatalf("Write() error = %v; want %v", err, ErrInvalidRecipe)
+	}
+
+	written, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("failed to read recipe: %v", err)
+	}
+	if string(written) != contents {
+		t.Errorf("recipe = %q; want it untouched", written)
+	}
+}
+
+func TestWriteMissingDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing") + "/"
+
+	err := Write(dir, []*model.Table{{Name: "users", Schema: "public", Columns: []model.Column{}}})
+	if err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+
+	tables, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read() unexpected error: %v", err)
+	}
+	if got := tableNames(tables); !slices.Equal(got, []string{"public.users"}) {
+		t.Errorf("Read() tables = %v; want [public.users]", got)
+	}
+}
+
+func values(tables []*model.Table) []model.Table {
+	var values []model.Table
+	for _, table := range tables {
+		values = append(values, *table)
+	}
+	return values
+}
+
+func TestSchemaMapping(t *testing.T) {
+	dir := t.TempDir()
+	writeRecipeFile(t, filepath.Join(dir, "shop.recipe.json"),
+		`{"version": 2, "schemaMapping": {"shop": "shop_ci"}, "tables": [{"tableName": "orders", "tableSchema": "shop", "tableColumns": []}]}`)
+	writeRecipeFile(t, filepath.Join(dir, "main.recipe.json"),
+		`{"version": 2, "include": ["shop.recipe.json"], "tables": [{"tableName": "users", "tableSchema": "public", "tableColumns": []}]}`)
+
+	tables, err := Read(filepath.Join(dir, "main.recipe.json"))
+	if err != nil {
+		t.Fatalf("Read() unexpected error: %v", err)
+	}
+	if tables[0].WriteSchema() != "shop_ci" || tables[1].WriteSchema() != "public" {
+		t.Errorf("Read() write schemas = %s, %s; want shop_ci, public", tables[0].WriteSchema(), tables[1].WriteSchema())
+	}
+
+	// the mapping survives rewriting and flattening the recipe
+	if err := Write(filepath.Join(dir, "main.recipe.json"), pointers(tables)); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	if err := Convert(filepath.Join(dir, "main.recipe.json"), filepath.Join(dir, "flat.yaml")); err != nil {
+		t.Fatalf("Convert() unexpected error: %v", err)
+	}
+	flat, _, err := readFile(filepath.Join(dir, "flat.yaml"))
+	if err != nil {
+		t.Fatalf("readFile() unexpected error: %v", err)
+	}
+	if flat.SchemaMapping["shop"] != "shop_ci" || len(flat.SchemaMapping) != 1 {
+		t.Errorf("converted schema mapping = %v; want map[shop:shop_ci]", flat.SchemaMapping)
+	}
+}
+
+func TestSchemaMappingErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "conflicting mapping",
+			files: map[string]string{
+				"main.recipe.json":  `{"version": 2, "include": ["other.recipe.json"], "schemaMapping": {"shop": "a"}, "tables": []}`,
+				"other.recipe.json": `{"version": 2, "schemaMapping": {"shop": "b"}, "tables": []}`,
+			},
+			wantErr: "schema shop is mapped to",
+		},
+		{
+			name: "invalid target schema",
+			files: map[string]string{
+				"main.recipe.json": `{"version": 2, "schemaMapping": {"shop": 1, "public": ""}, "tables": []}`,
+			},
+			wantErr: "schemaMapping.public: shorter than 1 characters\nschemaMapping.shop: expected string, got number",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, contents := range tc.files {
+				writeRecipeFile(t, filepath.Join(dir, name), contents)
+			}
+
+			_, err := Read(filepath.Join(dir, "main.recipe.json"))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("Read() error = %v; want containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
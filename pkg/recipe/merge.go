@@ -0,0 +1,171 @@
+package recipe
+
+import (
+	"dbaker/pkg/model"
+	"fmt"
+	"slices"
+)
+
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "+"
+	Removed ChangeKind = "-"
+	Changed ChangeKind = "~"
+)
+
+// Change is a difference between the recipe and the live database schema
+type Change struct {
+	Kind   ChangeKind
+	Table  string
+	Column string
+	Detail string
+}
+
+func (c Change) String() string {
+	subject := "table " + c.Table
+	if c.Column != "" {
+		subject = "column " + c.Table + "." + c.Column
+	}
+	if c.Detail == "" {
+		return fmt.Sprintf("%s %s", c.Kind, subject)
+	}
+	return fmt.Sprintf("%s %s: %s", c.Kind, subject, c.Detail)
+}
+
+// Merge merges freshly introspected tables into the existing recipe. The schema part of columns
+// (type, length, nullability, uniqueness, keys and check constraints) is taken from the live tables,
+// user authored settings (row counts, tag columns, annotations, null ratios, distributions, masks and stats) are kept.
+// New tables and columns are added, dropped columns are removed, recipe tables which were not
+// introspected are kept as they are. Every difference is returned as a change.
+func Merge(existing []model.Table, live []*model.Table) ([]*model.Table, []Change) {
+	var merged []*model.Table
+	var changes []Change
+
+	for index := range existing {
+		table := &existing[index]
+		liveIndex := slices.IndexFunc(live, func(liveTable *model.Table) bool { return sameTable(*table, *liveTable) })
+		if liveIndex < 0 {
+			merged = append(merged, table)
+			continue
+		}
+
+		mergedTable, tableChanges := mergeTable(*table, *live[liveIndex])
+		merged = append(merged, mergedTable)
+		changes = append(changes, tableChanges...)
+	}
+
+	for _, liveTable := range live {
+		if !slices.ContainsFunc(existing, func(table model.Table) bool { return sameTable(table, *liveTable) }) {
+			merged = append(merged, liveTable)
+			changes = append(changes, Change{Kind: Added, Table: tableName(*liveTable)})
+		}
+	}
+
+	return merged, changes
+}
+
+// Diff compares the recipe tables with their live versions, recipe tables missing in the database are
+// reported as removed. Only schema differences are reported, user authored settings are not compared.
+func Diff(tables []model.Table, live []*model.Table) []Change {
+	var changes []Change
+	for _, table := range tables {
+		liveIndex := slices.IndexFunc(live, func(liveTable *model.Table) bool { return sameTable(table, *liveTable) })
+		if liveIndex < 0 {
+			changes = append(changes, Change{Kind: Removed, Table: tableName(table)})
+			continue
+		}
+
+		_, tableChanges := mergeTable(table, *live[liveIndex])
+		changes = append(changes, tableChanges...)
+	}
+
+	return changes
+}
+
+func mergeTable(existing model.Table, live model.Table) (*model.Table, []Change) {
+	var changes []Change
+	name := tableName(live)
+
+	merged := live
+	merged.RowCount = existing.RowCount
+	merged.TagColumn = existing.TagColumn
+	merged.Columns = make([]model.Column, len(live.Columns))
+
+	for index, liveColumn := range live.Columns {
+		existingIndex := slices.IndexFunc(existing.Columns, func(column model.Column) bool { return column.Name == liveColumn.Name })
+		if existingIndex < 0 {
+			merged.Columns[index] = liveColumn
+			changes = append(changes, Change{Kind: Added, Table: name, Column: liveColumn.Name, Detail: string(liveColumn.Typ)})
+			continue
+		}
+
+		existingColumn := existing.Columns[existingIndex]
+		for _, detail := range compareColumns(existingColumn, liveColumn) {
+			changes = append(changes, Change{Kind: Changed, Table: name, Column: liveColumn.Name, Detail: detail})
+		}
+		merged.Columns[index] = mergeColumn(existingColumn, liveColumn)
+	}
+
+	for _, column := range existing.Columns {
+		if !slices.ContainsFunc(live.Columns, func(liveColumn model.Column) bool { return liveColumn.Name == column.Name }) {
+			changes = append(changes, Change{Kind: Removed, Table: name, Column: column.Name})
+		}
+	}
+
+	return &merged, changes
+}
+
+func mergeColumn(existing model.Column, live model.Column) model.Column {
+	merged := live
+	merged.NullRatio = existing.NullRatio
+	merged.Distribution = existing.Distribution
+	merged.Mask = existing.Mask
+	merged.Stats = existing.Stats
+
+	// an empty annotation in the recipe means nothing was inferred, a newly inferred one is taken over,
+	// unless the column has a distribution, which an annotation would take precedence over
+	if existing.Annotation != "" || existing.Distribution != nil {
+		merged.Annotation = existing.Annotation
+	}
+
+	return merged
+}
+
+// compareColumns describes the schema differences of two versions of a column
+func compareColumns(old model.Column, new model.Column) []string {
+	var details []string
+	if old.Typ != new.Typ {
+		details = append(details, fmt.Sprintf("type %s -> %s", old.Typ, new.Typ))
+	}
+	if old.MaxLength != new.MaxLength {
+		details = append(details, fmt.Sprintf("max length %d -> %d", old.MaxLength, new.MaxLength))
+	}
+	if old.IsNullable != new.IsNullable {
+		details = append(details, fmt.Sprintf("nullable %t -> %t", old.IsNullable, new.IsNullable))
+	}
+	if old.IsUnique != new.IsUnique {
+		details = append(details, fmt.Sprintf("unique %t -> %t", old.IsUnique, new.IsUnique))
+	}
+	if old.IsGenerated != new.IsGenerated {
+		details = append(details, fmt.Sprintf("generated %t -> %t", old.IsGenerated, new.IsGenerated))
+	}
+	if old.ForeignKey != new.ForeignKey {
+		details = append(details, fmt.Sprintf("foreign key '%s' -> '%s'", old.ForeignKey, new.ForeignKey))
+	}
+	if !slices.EqualFunc(old.Constraints, new.Constraints, func(a model.Constraint, b model.Constraint) bool {
+		return a.Op == b.Op && a.Value == b.Value && slices.Equal(a.Values, b.Values) && a.Column == b.Column && a.OnLength == b.OnLength
+	}) {
+		details = append(details, "check constraints changed")
+	}
+
+	return details
+}
+
+func sameTable(a model.Table, b model.Table) bool {
+	return a.Name == b.Name && a.Schema == b.Schema
+}
+
+func tableName(table model.Table) string {
+	return table.Schema + "." + table.Name
+}
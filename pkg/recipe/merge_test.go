@@ -0,0 +1,123 @@
+package recipe
+
+import (
+	"dbaker/pkg/model"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	ratio := 0.2
+	profiled := &model.Distribution{Kind: model.Normal, Mean: model.NumberParam(1.7), StdDev: model.NumberParam(0.1)}
+	existing := []model.Table{
+		{
+			Name:      "users",
+			Schema:    "public",
+			RowCount:  500,
+			TagColumn: "nickname",
+			Columns: []model.Column{
+				{Name: "id", Typ: model.Int, IsUnique: true},
+				{Name: "email", Typ: model.Varchar, MaxLength: 100, Annotation: "email", Mask: model.MaskFormat},
+				{Name: "nickname", Typ: model.Varchar, IsNullable: true, NullRatio: &ratio},
+				{Name: "legacy", Typ: model.Text},
+				{Name: "height", Typ: model.Double, Distribution: profiled},
+			},
+		},
+		{Name: "groups", Schema: "public", RowCount: 10, Columns: []model.Column{{Name: "id", Typ: model.Int}}},
+	}
+	live := []*model.Table{
+		{
+			Name:   "users",
+			Schema: "public",
+			Columns: []model.Column{
+				{Name: "id", Typ: model.BigInt, IsUnique: true},
+				{Name: "email", Typ: model.Varchar, MaxLength: 100, Annotation: "email"},
+				{Name: "nickname", Typ: model.Varchar, IsNullable: true, Annotation: "username"},
+				{Name: "age", Typ: model.Int, Annotation: "intRange(18,90)"},
+				{Name: "height", Typ: model.Double, Annotation: "price(1,1000)"},
+			},
+		},
+		{Name: "orders", Schema: "public", Columns: []model.Column{{Name: "id", Typ: model.Int}}},
+	}
+
+	merged, changes := Merge(existing, live)
+
+	if len(merged) != 3 || merged[0].Name != "users" || merged[1].Name != "groups" || merged[2].Name != "orders" {
+		t.Fatalf("Merge() tables = %v; want users, groups, orders", merged)
+	}
+
+	users := merged[0]
+	if users.RowCount != 500 {
+		t.Errorf("Merge() row count = %d; want the recipe row count kept", users.RowCount)
+	}
+	if users.TagColumn != "nickname" {
+		t.Errorf("Merge() tag column = %s; want the recipe tag column kept", users.TagColumn)
+	}
+
+	expectedColumns := []model.Column{
+		{Name: "id", Typ: model.BigInt, IsUnique: true},
+		{Name: "email", Typ: model.Varchar, MaxLength: 100, Annotation: "email", Mask: model.MaskFormat},
+		{Name: "nickname", Typ: model.Varchar, IsNullable: true, NullRatio: &ratio, Annotation: "username"},
+		{Name: "age", Typ: model.Int, Annotation: "intRange(18,90)"},
+		// the profiled distribution is not overridden by the inferred annotation
+		{Name: "height", Typ: model.Double, Distribution: profiled},
+	}
+	if !reflect.DeepEqual(users.Columns, expectedColumns) {
+		t.Errorf("Merge() columns = %+v; want %+v", users.Columns, expectedColumns)
+	}
+
+	if merged[1] != &existing[1] {
+		t.Errorf("Merge() groups = %v; want the table which was not introspected kept", merged[1])
+	}
+
+	expectedChanges := []string{
+		"~ column public.users.id: type int4 -> bigint",
+		"+ column public.users.age: int4",
+		"- column public.users.legacy",
+		"+ table public.orders",
+	}
+	var gotChanges []string
+	for _, change := range changes {
+		gotChanges = append(gotChanges, change.String())
+	}
+	if !reflect.DeepEqual(gotChanges, expectedChanges) {
+		t.Errorf("Merge() changes = %q; want %q", gotChanges, expectedChanges)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tables := []model.Table{
+		{Name: "users", Schema: "public", Columns: []model.Column{
+			{Name: "id", Typ: model.Int, IsUnique: true},
+			{Name: "group_id", Typ: model.Int, ForeignKey: "public.groups.id"},
+			{Name: "email", Typ: model.Varchar, Annotation: "email"},
+		}},
+		{Name: "groups", Schema: "public", Columns: []model.Column{{Name: "id", Typ: model.Int}}},
+	}
+	live := []*model.Table{
+		{Name: "users", Schema: "public", Columns: []model.Column{
+			{Name: "id", Typ: model.Int},
+			{Name: "group_id", Typ: model.Int, IsNullable: true},
+			{Name: "email", Typ: model.Varchar},
+		}},
+	}
+
+	var gotChanges []string
+	for _, change := range Diff(tables, live) {
+		gotChanges = append(gotChanges, change.String())
+	}
+
+	expectedChanges := []string{
+		"~ column public.users.id: unique true -> false",
+		"~ column public.users.group_id: nullable false -> true",
+		"~ column public.users.group_id: foreign key 'public.groups.id' -> ''",
+		"- table public.groups",
+	}
+	if !reflect.DeepEqual(gotChanges, expectedChanges) {
+		t.Errorf("Diff() = %q; want %q", gotChanges, expectedChanges)
+	}
+
+	if changes := Diff(tables[1:], []*model.Table{&tables[1]}); len(changes) != 0 {
+		t.Errorf("Diff() = %v; want no changes", changes)
+	}
+}
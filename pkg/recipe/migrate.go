@@ -0,0 +1,89 @@
+package recipe
+
+import (
+	"fmt"
+)
+
+// CurrentVersion is the recipe version written by this build
+const CurrentVersion = 2
+
+// migration upgrades the decoded recipe from its version to the next one
+type migration func(value any) (any, error)
+
+// migrations are indexed by the version they upgrade from
+var migrations = map[int]migration{
+	1: migrateV1,
+}
+
+// migrate upgrades the decoded recipe to the current version, returns the version the recipe had
+func migrate(value any) (any, int, error) {
+	version, err := versionOf(value)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if version > CurrentVersion {
+		return nil, 0, fmt.Errorf("recipe version %d is newer than the supported version %d, upgrade dbaker", version, CurrentVersion)
+	}
+
+	for from := version; from < CurrentVersion; from++ {
+		if value, err = migrations[from](value); err != nil {
+			return nil, 0, fmt.Errorf("failed to upgrade recipe from version %d: %w", from, err)
+		}
+	}
+
+	return value, version, nil
+}
+
+// versionOf detects the recipe version, version 1 recipes are a bare array of tables
+func versionOf(value any) (int, error) {
+	switch v := value.(type) {
+	case []any:
+		return 1, nil
+	case map[string]any:
+		number, ok := v["version"].(float64)
+		if !ok || number < 1 || number != float64(int(number)) {
+			return 0, fmt.Errorf("version: expected a positive integer, got %v", v["version"])
+		}
+		return int(number), nil
+	default:
+		return 0, fmt.Errorf("expected a recipe object, got %s", typeName(value))
+	}
+}
+
+// migrateV1 wraps the tables into the versioned recipe object (unless versioned already) and
+// renames the misspelled foreginKey column property to foreignKey
+func migrateV1(value any) (any, error) {
+	if recipe, ok := value.(map[string]any); ok {
+		value = recipe["tables"]
+	}
+	tables, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("tables: expected an array, got %s", typeName(value))
+	}
+
+	for _, table := range tables {
+		table, ok := table.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		columns, _ := table["tableColumns"].([]any)
+		for _, column := range columns {
+			column, ok := column.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			if foreignKey, ok := column["foreginKey"]; ok {
+				column["foreignKey"] = foreignKey
+				delete(column, "foreginKey")
+			}
+		}
+	}
+
+	return map[string]any{
+		"version": float64(2),
+		"tables":  tables,
+	}, nil
+}
@@ -0,0 +1,141 @@
+package recipe
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const v1Recipe = `[
+  {
+    "tableName": "orders",
+    "tableSchema": "public",
+    "tableColumns": [
+      { "columnName": "id", "columnType": "int4", "isUnique": true, "isGenerated": true, "isNullable": false },
+      { "columnName": "customer_id", "columnType": "int4", "isUnique": false, "isGenerated": false, "isNullable": false, "foreginKey": "public.customers.id" }
+    ]
+  }
+]`
+
+func TestReadUpgradesOlderVersions(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.recipe.json")
+	if err := os.WriteFile(filePath, []byte(v1Recipe), 0644); err != nil {
+		t.Fatalf("failed to write recipe: %v", err)
+	}
+
+	tables, err := Read(filePath)
+	if err != nil {
+		t.Fatalf("Read() unexpected error: %v", err)
+	}
+
+	if len(tables) != 1 || len(tables[0].Columns) != 2 {
+		t.Fatalf("Read() = %v; want one table with two columns", tables)
+	}
+	if foreignKey := tables[0].Columns[1].ForeignKey; foreignKey != "public.customers.id" {
+		t.Errorf("Read() foreign key = %q; want the misspelled property migrated", foreignKey)
+	}
+}
+
+func TestReadUpgradesVersionedV1Recipe(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.recipe.json")
+	if err := os.WriteFile(filePath, []byte(`{"version": 1, "tables": `+v1Recipe+`}`), 0644); err != nil {
+		t.Fatalf("failed to write recipe: %v", err)
+	}
+
+	tables, err := Read(filePath)
+	if err != nil {
+		t.Fatalf("Read() unexpected error: %v", err)
+	}
+	if len(tables) != 1 || tables[0].Columns[1].ForeignKey != "public.customers.id" {
+		t.Errorf("Read() = %v; want the tables of the versioned recipe migrated", tables)
+	}
+
+	if err := os.WriteFile(filePath, []byte(`{"version": 1, "tables": {}}`), 0644); err != nil {
+		t.Fatalf("failed to write recipe: %v", err)
+	}
+	if _, err := Read(filePath); !errors.Is(err, ErrInvalidRecipe) {
+		t.Errorf("Read() error = %v; want %v", err, ErrInvalidRecipe)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.recipe.json")
+	if err := os.WriteFile(filePath, []byte(v1Recipe), 0644); err != nil {
+		t.Fatalf("failed to write recipe: %v", err)
+	}
+
+	version, err := Upgrade(filePath, false)
+	if err != nil {
+		t.Fatalf("Upgrade() unexpected error: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("Upgrade() = %d; want 1", version)
+	}
+
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("failed to read upgraded recipe: %v", err)
+	}
+	if err := Validate(contents); err != nil {
+		t.Errorf("upgraded recipe is invalid: %v", err)
+	}
+	if !strings.Contains(string(contents), `"foreignKey": "public.customers.id"`) {
+		t.Errorf("upgraded recipe = %s; want foreignKey property", contents)
+	}
+
+	version, err = Upgrade(filePath, false)
+	if err != nil || version != CurrentVersion {
+		t.Errorf("Upgrade() = %d, %v; want current version %d", version, err, CurrentVersion)
+	}
+}
+
+func TestUpgradeKeepsCommentedRecipes(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.recipe.yaml")
+	contents := "# orders of the shop\n- tableName: orders\n  tableSchema: public\n  tableColumns: []\n"
+	if err := os.WriteFile(filePath, []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write recipe: %v", err)
+	}
+
+	if _, err := Upgrade(filePath, false); !errors.Is(err, ErrCommentsLost) {
+		t.Fatalf("Upgrade() error = %v; want %v", err, ErrCommentsLost)
+	}
+	if written, _ := os.ReadFile(filePath); string(written) != contents {
+		t.Fatalf("recipe = %q; want it untouched", written)
+	}
+
+	if version, err := Upgrade(filePath, true); err != nil || version != 1 {
+		t.Fatalf("Upgrade() forced = %d, %v; want upgraded from version 1", version, err)
+	}
+	if _, version, err := readFile(filePath); err != nil || version != CurrentVersion {
+		t.Errorf("upgraded recipe version = %d, %v; want %d", version, err, CurrentVersion)
+	}
+}
+
+func TestMigrateRejectsUnknownVersions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+		wantErr  string
+	}{
+		{name: "newer version", contents: `{"version": 99, "tables": []}`, wantErr: "newer than the supported version"},
+		{name: "missing version", contents: `{"tables": []}`, wantErr: "version: expected a positive integer"},
+		{name: "not a recipe", contents: `"recipe"`, wantErr: "expected a recipe object"},
+		{name: "version 1 object without tables", contents: `{"version": 1, "tables": "orders"}`, wantErr: "tables: expected an array"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := unmarshal([]byte(tc.contents))
+			if err != nil {
+				t.Fatalf("unmarshal() unexpected error: %v", err)
+			}
+
+			_, _, err = migrate(value)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("migrate() error = %v; want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,369 @@
+package recipe
+
+import (
+	"bytes"
+	"dbaker/pkg/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrInvalidRecipe = errors.New("invalid recipe")
+	// rewriting YAML or TOML recipe files drops their comments
+	ErrCommentsLost = errors.New("recipe comments would be lost")
+)
+
+// Recipe is the recipe file contents, tables of included files (paths relative to the file,
+// directories included) come before the tables of the file itself
+type Recipe struct {
+	Version int      `json:"version"`
+	Include []string `json:"include,omitempty"`
+	// target schema names by the schema names of the tables, applies to the tables of all files read
+	SchemaMapping map[string]string `json:"schemaMapping,omitempty"`
+	Tables        []*model.Table    `json:"tables"`
+}
+
+// Read reads the recipe (JSON, YAML or TOML by the extension) along with its included files,
+// a directory reads all recipe files within. Files are upgraded to the current version
+// and validated against the schema before decoding. Tables get their target schema by the schema mapping.
+func Read(recipePath string) ([]model.Table, error) {
+	reader := reader{visiting: make(map[string]bool), origins: make(map[string]string), mapping: make(map[string]mappedSchema)}
+	if err := reader.read(recipePath); err != nil {
+		return nil, err
+	}
+
+	for index := range reader.tables {
+		table := &reader.tables[index]
+		if mapped, ok := reader.mapping[table.Schema]; ok && mapped.schema != table.Schema {
+			table.TargetSchema = mapped.schema
+		}
+	}
+
+	return reader.tables, nil
+}
+
+// Write writes the tables into the recipe in the current version. Tables defined in files included
+// by the recipe (or files of a recipe directory) are written back into these files, other tables are
+// written into the recipe file itself, or into a new file per table for a recipe directory.
+func Write(recipePath string, tables []*model.Table) error {
+	if isDir(recipePath) {
+		return writeDir(recipePath, tables)
+	}
+
+	// an existing recipe which can't be read is not replaced, its includes and schema mapping would be lost
+	var include []string
+	var schemaMapping map[string]string
+	existing, err := readExisting(recipePath)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		include = existing.Include
+		schemaMapping = existing.SchemaMapping
+	}
+
+	var includePaths []string
+	for _, included := range include {
+		includePaths = append(includePaths, resolveInclude(recipePath, included))
+	}
+
+	own, err := writeIncluded(includePaths, tables)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(recipePath, &Recipe{Version: CurrentVersion, Include: include, SchemaMapping: schemaMapping, Tables: own})
+}
+
+// Upgrade rewrites the recipe file (without its includes) in the current version, returns the version the file had.
+// Files holding comments are rewritten only when forced, the comments are lost.
+func Upgrade(filePath string, force bool) (int, error) {
+	recipe, version, err := readFile(filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	if version == CurrentVersion {
+		return version, nil
+	}
+
+	if !force {
+		commented, err := fileHasComments(filePath)
+		if err != nil {
+			return version, err
+		}
+		if commented {
+			return version, commentsLostError([]string{filePath})
+		}
+	}
+
+	return version, writeFile(filePath, recipe)
+}
+
+// Convert converts the recipe into a single file of another format (and the current version), e.g. JSON into YAML
+func Convert(fromRecipePath string, toFilePath string) error {
+	tables, err := Read(fromRecipePath)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(toFilePath, &Recipe{Version: CurrentVersion, SchemaMapping: schemaMapping(tables), Tables: pointers(tables)})
+}
+
+// schemaMapping collects the schema mapping back from the target schemas of the tables
+func schemaMapping(tables []model.Table) map[string]string {
+	var mapping map[string]string
+	for _, table := range tables {
+		if table.TargetSchema == "" {
+			continue
+		}
+		if mapping == nil {
+			mapping = make(map[string]string)
+		}
+		mapping[table.Schema] = table.TargetSchema
+	}
+	return mapping
+}
+
+// CheckComments fails with ErrCommentsLost when YAML or TOML files of the recipe (along with its includes
+// and the files of recipe directories) hold comments, which are lost when the recipe is written
+func CheckComments(recipePath string) error {
+	var commented []string
+	if err := collectCommented(recipePath, make(map[string]bool), &commented); err != nil {
+		return err
+	}
+	if len(commented) > 0 {
+		return commentsLostError(commented)
+	}
+	return nil
+}
+
+func collectCommented(recipePath string, visited map[string]bool, commented *[]string) error {
+	filePaths := []string{recipePath}
+	if isDir(recipePath) {
+		if _, err := os.Stat(recipePath); errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		var err error
+		if filePaths, err = recipeFiles(recipePath); err != nil {
+			return err
+		}
+	}
+
+	for _, filePath := range filePaths {
+		absolutePath, err := filepath.Abs(filePath)
+		if err != nil {
+			return err
+		}
+		if visited[absolutePath] {
+			continue
+		}
+		visited[absolutePath] = true
+
+		existing, err := readExisting(filePath)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			continue
+		}
+		hasComments, err := fileHasComments(filePath)
+		if err != nil {
+			return err
+		}
+		if hasComments {
+			*commented = append(*commented, filePath)
+		}
+
+		for _, included := range existing.Include {
+			if err := collectCommented(resolveInclude(filePath, included), visited, commented); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func fileHasComments(filePath string) (bool, error) {
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read recipe: %w", err)
+	}
+
+	commented, err := hasComments(formatOf(filePath), contents)
+	if err != nil {
+		return false, &InvalidError{filePath, err}
+	}
+	return commented, nil
+}
+
+func commentsLostError(filePaths []string) error {
+	return fmt.Errorf("%w: %s hold comments which rewriting drops, use --force to rewrite anyway", ErrCommentsLost, strings.Join(filePaths, ", "))
+}
+
+// readExisting reads the recipe file about to be written, returns nil for a missing or empty file
+func readExisting(filePath string) (*Recipe, error) {
+	contents, err := os.ReadFile(filePath)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && len(bytes.TrimSpace(contents)) == 0) {
+		return nil, nil
+	}
+
+	recipe, _, err := readFile(filePath)
+	return recipe, err
+}
+
+// readFile reads a single recipe file without resolving its includes
+func readFile(filePath string) (*Recipe, int, error) {
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read recipe: %w", err)
+	}
+
+	recipe, version, err := decode(rootSchema, formatOf(filePath), contents)
+	if err != nil {
+		return nil, 0, &InvalidError{filePath, err}
+	}
+
+	return recipe, version, nil
+}
+
+// ReadOverlay reads a recipe file of overrides (e.g. the bake overlay), which lists only the tables and
+// columns it changes: these need nothing but their names, column types are taken from the database.
+// Includes and the schema mapping of the overlay are not applied.
+func ReadOverlay(filePath string) ([]model.Table, error) {
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recipe: %w", err)
+	}
+
+	recipe, _, err := decode(overlaySchema, formatOf(filePath), contents)
+	if err != nil {
+		return nil, &InvalidError{filePath, err}
+	}
+
+	tables := make([]model.Table, len(recipe.Tables))
+	for index, table := range recipe.Tables {
+		tables[index] = *table
+	}
+	return tables, nil
+}
+
+func writeFile(filePath string, recipe *Recipe) error {
+	json, err := marshal(recipe)
+	if err != nil {
+		return err
+	}
+
+	contents, err := fromJSON(formatOf(filePath), json)
+	if err != nil {
+		return fmt.Errorf("failed to convert recipe: %w", err)
+	}
+
+	err = os.WriteFile(filePath, contents, 0644)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func pointers(tables []model.Table) []*model.Table {
+	pointers := make([]*model.Table, len(tables))
+	for index := range tables {
+		pointers[index] = &tables[index]
+	}
+	return pointers
+}
+
+// ValidateTables validates the tables as they would be written into the recipe file
+func ValidateTables(tables []*model.Table) error {
+	contents, err := marshal(&Recipe{Version: CurrentVersion, Tables: tables})
+	if err != nil {
+		return err
+	}
+
+	return Validate(contents)
+}
+
+// Validate validates the recipe file contents (in the current version) against the schema,
+// every violation is reported with the path of the offending value
+func Validate(contents []byte) error {
+	value, err := unmarshal(contents)
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(validateValue(rootSchema, rootSchema, value, "")...)
+}
+
+// decode migrates the contents to the current version, validates them against the schema and decodes them
+func decode(schema *schemaNode, format format, contents []byte) (*Recipe, int, error) {
+	contents, err := toJSON(format, contents)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value, err := unmarshal(contents)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value, version, err := migrate(value)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := errors.Join(validateValue(schema, schema, value, "")...); err != nil {
+		return nil, 0, err
+	}
+
+	// the value is valid JSON already, a round trip maps it onto the model
+	contents, err = json.Marshal(value)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var recipe Recipe
+	if err := json.Unmarshal(contents, &recipe); err != nil {
+		return nil, 0, err
+	}
+
+	return &recipe, version, nil
+}
+
+func marshal(recipe *Recipe) ([]byte, error) {
+	if recipe.Tables == nil {
+		recipe.Tables = []*model.Table{}
+	}
+
+	return json.MarshalIndent(recipe, "", "  ")
+}
+
+func unmarshal(contents []byte) (any, error) {
+	var value any
+	if err := json.Unmarshal(contents, &value); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line, column := position(contents, syntaxErr.Offset)
+			return nil, fmt.Errorf("line %d, column %d: %w", line, column, err)
+		}
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// position returns the line and column of the character read last before the offset
+func position(contents []byte, offset int64) (int, int) {
+	offset = max(0, min(offset-1, int64(len(contents))))
+	before := contents[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
@@ -0,0 +1,211 @@
+package recipe
+
+import (
+	"dbaker/pkg/model"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	testCases := []struct {
+		name    string
+		tables  []*model.Table
+		wantErr bool
+	}{
+		{
+			name:    "empty tables slice",
+			tables:  []*model.Table{},
+			wantErr: false,
+		},
+		{
+			name: "single table",
+			tables: []*model.Table{
+				{
+					Name:   "users",
+					Schema: "public",
+					Columns: []model.Column{
+						{Name: "id", Typ: model.Int, IsGenerated: true},
+						{Name: "name", Typ: model.Varchar, MaxLength: 255, IsNullable: false},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "multiple tables",
+			tables: []*model.Table{
+				{
+					Name:   "users",
+					Schema: "public",
+					Columns: []model.Column{
+						{Name: "id", Typ: model.Int, IsGenerated: true},
+						{Name: "name", Typ: model.Varchar, MaxLength: 255, IsNullable: false},
+					},
+				},
+				{
+					Name:   "products",
+					Schema: "public",
+					Columns: []model.Column{
+						{Name: "product_id", Typ: model.UUID, IsUnique: true},
+						{Name: "price", Typ: model.Decimal, IsNullable: false},
+					},
+				},
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpfile, err := os.CreateTemp("", "test_recipe_*.json")
+			if err != nil {
+				t.Fatalf("failed to create temporary file: %v", err)
+			}
+			defer os.Remove(tmpfile.Name())
+			tmpfile.Close() // Close the file handle so Write can open it
+
+			err = Write(tmpfile.Name(), tc.tables)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Write() error = %v, wantErr %v", err, tc.wantErr)
+				return
+			}
+
+			if !tc.wantErr {
+				contents, err := os.ReadFile(tmpfile.Name())
+				if err != nil {
+					t.Fatalf("failed to read written file: %v", err)
+				}
+
+				var recipe Recipe
+				err = json.Unmarshal(contents, &recipe)
+				if err != nil {
+					t.Fatalf("failed to unmarshal JSON from file: %v", err)
+				}
+				if recipe.Version != CurrentVersion {
+					t.Errorf("written version = %d; want %d", recipe.Version, CurrentVersion)
+				}
+				readTables := recipe.Tables
+
+				// Marshal both original and read tables to canonical JSON for comparison
+				// Using json.Marshal (without indent) ensures consistent comparison regardless of formatting
+				originalJSON, err := json.Marshal(tc.tables)
+				if err != nil {
+					t.Fatalf("failed to marshal original tables for comparison: %v", err)
+				}
+				readJSON, err := json.Marshal(readTables)
+				if err != nil {
+					t.Fatalf("failed to marshal read tables for comparison: %v", err)
+				}
+
+				if string(originalJSON) != string(readJSON) {
+					t.Errorf("written JSON does not match original.\nExpected: %s\nGot: %s", string(originalJSON), string(readJSON))
+				}
+
+				// the written recipe reads back into the same tables
+				roundTrip, err := Read(tmpfile.Name())
+				if err != nil {
+					t.Fatalf("Read() unexpected error: %v", err)
+				}
+				if len(roundTrip) != len(tc.tables) {
+					t.Fatalf("Read() = %d tables; want %d", len(roundTrip), len(tc.tables))
+				}
+				for index, table := range roundTrip {
+					if !reflect.DeepEqual(table, *tc.tables[index]) {
+						t.Errorf("Read() table %d = %+v; want %+v", index, table, *tc.tables[index])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+		wantErrs []string
+	}{
+		{
+			name:     "valid recipe",
+			contents: `[{"tableName": "users", "tableSchema": "public", "rowCount": 10, "tableColumns": [{"columnName": "id", "columnType": "int4", "isUnique": true, "distribution": {"kind": "uniform", "min": 1, "max": "10"}}]}]`,
+		},
+		{
+			name:     "unknown column type",
+			contents: `[{"tableName": "a", "tableColumns": []}, {"tableName": "b", "tableColumns": [{"columnName": "id", "columnType": "int4"}, {"columnName": "doc", "columnType": "jsonb"}]}]`,
+			wantErrs: []string{"tables[1].tableColumns[1].columnType: unknown type 'jsonb'"},
+		},
+		{
+			name:     "missing and unknown properties",
+			contents: `[{"tableName": "users", "tableColumns": [{"columnName": "id", "colType": "int4"}]}]`,
+			wantErrs: []string{
+				"tables[0].tableColumns[0]: missing property 'columnType'",
+				"tables[0].tableColumns[0].colType: unknown property",
+			},
+		},
+		{
+			name:     "wrong types and ranges",
+			contents: `[{"tableName": "users", "rowCount": -1, "tableColumns": [{"columnName": "id", "columnType": "int4", "isUnique": "yes", "nullRatio": 2}]}]`,
+			wantErrs: []string{
+				"tables[0].rowCount: -1 is less than the minimum 0",
+				"tables[0].tableColumns[0].isUnique: expected boolean, got string",
+				"tables[0].tableColumns[0].nullRatio: 2 is greater than the maximum 1",
+			},
+		},
+		{
+			name:     "nested distribution",
+			contents: `[{"tableName": "users", "tableColumns": [{"columnName": "age", "columnType": "int4", "distribution": {"kind": "pareto", "min": true}}]}]`,
+			wantErrs: []string{
+				"tables[0].tableColumns[0].distribution.kind: unknown distribution kind 'pareto'",
+				"tables[0].tableColumns[0].distribution.min: expected number or string, got boolean",
+			},
+		},
+		{
+			name:     "not an array",
+			contents: `{"tableName": "users"}`,
+			wantErrs: []string{"tables: expected array, got object"},
+		},
+		{
+			name:     "syntax error",
+			contents: "[\n  {\"tableName\": \"users\",}\n]",
+			wantErrs: []string{"line 2, column 25"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate([]byte(`{"version": 2, "tables": ` + tc.contents + `}`))
+			if len(tc.wantErrs) == 0 {
+				if err != nil {
+					t.Errorf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("Validate() expected errors %v", tc.wantErrs)
+			}
+			for _, wantErr := range tc.wantErrs {
+				if !strings.Contains(err.Error(), wantErr) {
+					t.Errorf("Validate() error = %q; want it to contain %q", err, wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestReadRejectsInvalidRecipe(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.recipe.json")
+	if err := os.WriteFile(filePath, []byte(`{"version": 2, "tables": [{"tableName": "users"}]}`), 0644); err != nil {
+		t.Fatalf("failed to write recipe: %v", err)
+	}
+
+	_, err := Read(filePath)
+	if !errors.Is(err, ErrInvalidRecipe) {
+		t.Errorf("Read() error = %v; want ErrInvalidRecipe", err)
+	}
+}
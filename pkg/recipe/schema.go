@@ -0,0 +1,233 @@
+package recipe
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is the JSON Schema of the recipe file
+//
+//go:embed recipe.schema.json
+var Schema []byte
+
+// ValidationError points at the recipe value which does not conform to the schema
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// schemaNode is the subset of JSON Schema keywords the recipe schema uses
+type schemaNode struct {
+	Ref                  string                 `json:"$ref"`
+	Defs                 map[string]*schemaNode `json:"$defs"`
+	Title                string                 `json:"title"`
+	Type                 schemaTypes            `json:"type"`
+	Enum                 []any                  `json:"enum"`
+	Properties           map[string]*schemaNode `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *additionalProperties  `json:"additionalProperties"`
+	Items                *schemaNode            `json:"items"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	MinLength            *int                   `json:"minLength"`
+}
+
+// schemaTypes holds the type keyword given either as a single type or a list of types
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*t = multiple
+	return nil
+}
+
+// additionalProperties holds the additionalProperties keyword given either as a boolean
+// or as the schema of the properties not listed in properties
+type additionalProperties struct {
+	allowed bool
+	schema  *schemaNode
+}
+
+func (a *additionalProperties) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.allowed); err == nil {
+		return nil
+	}
+
+	a.allowed = true
+	return json.Unmarshal(data, &a.schema)
+}
+
+var rootSchema = mustParseSchema(Schema)
+
+// overlaySchema validates recipes of overrides, which list their tables and columns by name only
+var overlaySchema = mustParseOverlaySchema(Schema)
+
+func mustParseSchema(data []byte) *schemaNode {
+	var root schemaNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		panic(fmt.Sprintf("invalid embedded recipe schema: %v", err))
+	}
+	return &root
+}
+
+// mustParseOverlaySchema parses the recipe schema with tables and columns requiring nothing but their names
+func mustParseOverlaySchema(data []byte) *schemaNode {
+	root := mustParseSchema(data)
+	root.Defs["table"].Required = []string{"tableName"}
+	root.Defs["column"].Required = []string{"columnName"}
+	return root
+}
+
+// validateValue validates the decoded JSON value against the schema node, all violations are collected
+func validateValue(root *schemaNode, node *schemaNode, value any, path string) []error {
+	if node.Ref != "" {
+		name, ok := strings.CutPrefix(node.Ref, "#/$defs/")
+		if !ok || root.Defs[name] == nil {
+			return []error{&ValidationError{path, fmt.Sprintf("unresolvable schema reference '%s'", node.Ref)}}
+		}
+		node = root.Defs[name]
+	}
+
+	if len(node.Type) > 0 && !slices.ContainsFunc(node.Type, func(typ string) bool { return isType(value, typ) }) {
+		return []error{&ValidationError{path, fmt.Sprintf("expected %s, got %s", strings.Join(node.Type, " or "), typeName(value))}}
+	}
+
+	if len(node.Enum) > 0 && !slices.Contains(node.Enum, value) {
+		title := node.Title
+		if title == "" {
+			title = "value"
+		}
+		return []error{&ValidationError{path, fmt.Sprintf("unknown %s '%v', expected one of %s", title, value, formatEnum(node.Enum))}}
+	}
+
+	var errs []error
+	switch v := value.(type) {
+	case float64:
+		if node.Minimum != nil && v < *node.Minimum {
+			errs = append(errs, &ValidationError{path, fmt.Sprintf("%v is less than the minimum %v", v, *node.Minimum)})
+		}
+		if node.Maximum != nil && v > *node.Maximum {
+			errs = append(errs, &ValidationError{path, fmt.Sprintf("%v is greater than the maximum %v", v, *node.Maximum)})
+		}
+
+	case string:
+		if node.MinLength != nil && utf8.RuneCountInString(v) < *node.MinLength {
+			errs = append(errs, &ValidationError{path, fmt.Sprintf("shorter than %d characters", *node.MinLength)})
+		}
+
+	case []any:
+		if node.Items != nil {
+			for index, item := range v {
+				errs = append(errs, validateValue(root, node.Items, item, fmt.Sprintf("%s[%d]", path, index))...)
+			}
+		}
+
+	case map[string]any:
+		for _, name := range node.Required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, &ValidationError{path, fmt.Sprintf("missing property '%s'", name)})
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			property, ok := node.Properties[name]
+			if !ok {
+				switch additional := node.AdditionalProperties; {
+				case additional == nil:
+				case additional.schema != nil:
+					errs = append(errs, validateValue(root, additional.schema, v[name], joinPath(path, name))...)
+				case !additional.allowed:
+					errs = append(errs, &ValidationError{joinPath(path, name), "unknown property"})
+				}
+				continue
+			}
+			errs = append(errs, validateValue(root, property, v[name], joinPath(path, name))...)
+		}
+	}
+
+	return errs
+}
+
+func isType(value any, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return false
+	}
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+func formatEnum(values []any) string {
+	names := make([]string, len(values))
+	for index, value := range values {
+		names[index] = fmt.Sprint(value)
+	}
+	return strings.Join(names, ", ")
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}